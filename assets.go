@@ -0,0 +1,181 @@
+package bodytransformer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/beevik/etree"
+)
+
+// Actions of an AssetPolicy
+const (
+	// AssetRemove removes the element together with its text
+	AssetRemove = "remove"
+	// AssetUnwrap replaces the element with its text
+	AssetUnwrap = "unwrap"
+	// AssetLink replaces the element with a plain link to the canonical ft.com URL of the asset
+	AssetLink = "link"
+)
+
+// CanonicalContentURL is the ft.com URL assets are linked to, followed by their uuid
+const CanonicalContentURL = "https://www.ft.com/content/"
+
+// AssetPolicy is what happens to an element matched by an AttributeRule
+type AssetPolicy struct {
+	// Action is AssetRemove, AssetUnwrap or AssetLink
+	Action string `json:"action"`
+	// Label is the text of the link made by AssetLink, the text of the element is kept when empty
+	Label string `json:"label,omitempty"`
+}
+
+// AttributeRule applies a policy to the elements whose attribute value matches the rule. The value has to match all
+// of Values, Pattern and Match that are set, a rule without any of them matches every element with the attribute.
+// A rule whose element or attribute is not an XML name matches nothing.
+// Rules without Match can be loaded from configuration with ParseAttributeRules.
+type AttributeRule struct {
+	// Element is the tag name of the elements, e.g. a
+	Element string `json:"element"`
+	// Attribute is the name of the attribute tested, e.g. data-asset-type
+	Attribute string `json:"attribute"`
+	// Values, when set, are the attribute values the rule applies to
+	Values []string `json:"values,omitempty"`
+	// Pattern, when set, is a regular expression the whole attribute value has to match. A rule with an invalid
+	// pattern matches nothing.
+	Pattern string `json:"pattern,omitempty"`
+	// Match, when set, reports whether the policy applies to the element with the given attribute value
+	Match  func(value string) bool `json:"-"`
+	Policy AssetPolicy             `json:"policy"`
+}
+
+// ParseAttributeRules reads attribute rules from their JSON configuration, a list of objects with the element,
+// attribute, values, pattern and policy fields, and checks them
+func ParseAttributeRules(data []byte) ([]AttributeRule, error) {
+	var rules []AttributeRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse attribute rules: %w", err)
+	}
+	for i, rule := range rules {
+		switch {
+		case rule.Element == "" || rule.Attribute == "":
+			return nil, fmt.Errorf("attribute rule %d: element and attribute are required", i)
+		case !xmlNameRegex.MatchString(rule.Element):
+			return nil, fmt.Errorf("attribute rule %d: invalid element name %q", i, rule.Element)
+		case !xmlNameRegex.MatchString(rule.Attribute):
+			return nil, fmt.Errorf("attribute rule %d: invalid attribute name %q", i, rule.Attribute)
+		case rule.Policy.Action != AssetRemove && rule.Policy.Action != AssetUnwrap && rule.Policy.Action != AssetLink:
+			return nil, fmt.Errorf("attribute rule %d: unknown action %q", i, rule.Policy.Action)
+		}
+		if rule.Pattern != "" {
+			if _, err := regexp.Compile(rule.Pattern); err != nil {
+				return nil, fmt.Errorf("attribute rule %d: invalid pattern: %w", i, err)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// AttributeEquals returns a predicate matching any of the values
+func AttributeEquals(values ...string) func(string) bool {
+	return func(value string) bool {
+		return contains(values, value)
+	}
+}
+
+// defaultAttributeRules remove the video and interactive graphic anchors
+var defaultAttributeRules = []AttributeRule{
+	{Element: "a", Attribute: "data-asset-type", Values: []string{"video"}, Policy: AssetPolicy{Action: AssetRemove}},
+	{Element: "a", Attribute: "data-asset-type", Values: []string{"interactive-graphic"}, Policy: AssetPolicy{Action: AssetRemove}},
+}
+
+// AssetPolicies sets the policy applied to the anchors with the given data-asset-type values, e.g. video,
+// interactive-graphic, audio or podcast. The anchors of other asset types are handled by the default rules.
+func AssetPolicies(policies map[string]AssetPolicy) Option {
+	assetTypes := make([]string, 0, len(policies))
+	for assetType := range policies {
+		assetTypes = append(assetTypes, assetType)
+	}
+	sort.Strings(assetTypes)
+
+	rules := make([]AttributeRule, 0, len(policies))
+	for _, assetType := range assetTypes {
+		rules = append(rules, AttributeRule{
+			Element:   "a",
+			Attribute: "data-asset-type",
+			Values:    []string{assetType},
+			Policy:    policies[assetType],
+		})
+	}
+	return AttributeRules(rules...)
+}
+
+// AttributeRules applies the rules to the elements of the body. An element is handled by the first rule matching it,
+// the rules given to the option take precedence over the ones given before and over the default rules.
+func AttributeRules(rules ...AttributeRule) Option {
+	return func(cfg *config) {
+		cfg.attributeRules = append(append([]AttributeRule(nil), rules...), cfg.attributeRules...)
+	}
+}
+
+var uuidRegex = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+
+// xmlNameRegex matches the element and attribute names of the attribute rules, with an optional namespace prefix
+var xmlNameRegex = regexp.MustCompile(`^(?:[\p{L}_][\p{L}\p{N}_.-]*:)?[\p{L}_][\p{L}\p{N}_.-]*$`)
+
+// applyAttributeRules applies the rules, in order, to the elements of the document
+func applyAttributeRules(doc *etree.Document, rules []AttributeRule, report *Report) {
+	for _, rule := range rules {
+		// the names are part of the element path, which must not be malformed
+		if !xmlNameRegex.MatchString(rule.Element) || !xmlNameRegex.MatchString(rule.Attribute) {
+			continue
+		}
+		matches := rule.matcher()
+		for _, el := range doc.FindElements("//" + rule.Element + "[@" + rule.Attribute + "]") {
+			if el.Parent() == nil || !matches(el.SelectAttrValue(rule.Attribute, "")) {
+				continue
+			}
+			switch rule.Policy.Action {
+			case AssetUnwrap:
+				report.add(RuleUnwrapAsset, el)
+				unwrapElement(el)
+			case AssetLink:
+				report.add(RuleLinkAsset, el)
+				linkAsset(el, rule.Policy.Label)
+			default:
+				removeElement(el, RuleRemoveAsset, report)
+			}
+		}
+	}
+}
+
+// matcher returns the predicate testing the attribute values against the rule
+func (rule AttributeRule) matcher() func(string) bool {
+	var pattern *regexp.Regexp
+	if rule.Pattern != "" {
+		var err error
+		if pattern, err = regexp.Compile(`^(?:` + rule.Pattern + `)$`); err != nil {
+			return func(string) bool { return false }
+		}
+	}
+	return func(value string) bool {
+		return (len(rule.Values) == 0 || contains(rule.Values, value)) &&
+			(pattern == nil || pattern.MatchString(value)) &&
+			(rule.Match == nil || rule.Match(value))
+	}
+}
+
+// linkAsset turns el into a plain link to the canonical URL of the asset, keeping its href when it holds no uuid
+func linkAsset(el *etree.Element, label string) {
+	href := el.SelectAttrValue("href", "")
+	if uuid := uuidRegex.FindString(href); uuid != "" {
+		href = CanonicalContentURL + uuid
+	}
+	el.Tag = "a"
+	el.Attr = nil
+	el.CreateAttr("href", href)
+	if label != "" {
+		el.Child = nil
+		el.SetText(label)
+	}
+}
@@ -0,0 +1,141 @@
+package bodytransformer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAssetPolicies(t *testing.T) {
+	body := `<body><p>Watch <a href="https://www.ft.com/video/5e1e4d1c-0cd3-4b9d-a5ba-1b2b6e2f3d0a" data-asset-type="video">the interview</a> ` +
+		`and explore <a href="https://ig.ft.com/climate-tracker/" data-asset-type="interactive-graphic">our tracker</a> ` +
+		`or listen to <a href="https://www.ft.com/content/3f7c9a30-6d4b-4c58-9a0e-9d2f3c1b7e21" data-asset-type="podcast">the podcast</a>.</p></body>`
+
+	tests := map[string]struct {
+		opts     []Option
+		expected string
+		rules    map[string]int
+	}{
+		"defaults": {
+			expected: `<body><p>Watch  and explore  or listen to <a href="https://www.ft.com/content/3f7c9a30-6d4b-4c58-9a0e-9d2f3c1b7e21" data-asset-type="podcast">the podcast</a>.</p></body>`,
+			rules:    map[string]int{RuleRemoveAsset: 2},
+		},
+		"unwrap and link": {
+			opts: []Option{AssetPolicies(map[string]AssetPolicy{
+				"video":               {Action: AssetLink, Label: "Watch the video"},
+				"interactive-graphic": {Action: AssetUnwrap},
+				"podcast":             {Action: AssetLink},
+			})},
+			expected: `<body><p>Watch <a href="https://www.ft.com/content/5e1e4d1c-0cd3-4b9d-a5ba-1b2b6e2f3d0a">Watch the video</a> ` +
+				`and explore our tracker or listen to <a href="https://www.ft.com/content/3f7c9a30-6d4b-4c58-9a0e-9d2f3c1b7e21">the podcast</a>.</p></body>`,
+			rules: map[string]int{RuleLinkAsset: 2, RuleUnwrapAsset: 1},
+		},
+		"link without uuid keeps the href": {
+			opts:     []Option{AssetPolicies(map[string]AssetPolicy{"interactive-graphic": {Action: AssetLink}})},
+			expected: `<body><p>Watch  and explore <a href="https://ig.ft.com/climate-tracker/">our tracker</a> or listen to <a href="https://www.ft.com/content/3f7c9a30-6d4b-4c58-9a0e-9d2f3c1b7e21" data-asset-type="podcast">the podcast</a>.</p></body>`,
+			rules:    map[string]int{RuleRemoveAsset: 1, RuleLinkAsset: 1},
+		},
+		"attribute predicate": {
+			opts: []Option{AttributeRules(AttributeRule{
+				Element:   "a",
+				Attribute: "data-asset-type",
+				Match:     func(value string) bool { return value != "video" },
+				Policy:    AssetPolicy{Action: AssetUnwrap},
+			})},
+			expected: `<body><p>Watch  and explore our tracker or listen to the podcast.</p></body>`,
+			rules:    map[string]int{RuleRemoveAsset: 1, RuleUnwrapAsset: 2},
+		},
+		"declarative values and pattern": {
+			opts: []Option{AttributeRules(
+				AttributeRule{Element: "a", Attribute: "data-asset-type", Values: []string{"podcast"}, Policy: AssetPolicy{Action: AssetUnwrap}},
+				AttributeRule{Element: "a", Attribute: "href", Pattern: `https://ig\.ft\.com/.*`, Policy: AssetPolicy{Action: AssetLink}},
+			)},
+			expected: `<body><p>Watch  and explore <a href="https://ig.ft.com/climate-tracker/">our tracker</a> or listen to the podcast.</p></body>`,
+			rules:    map[string]int{RuleRemoveAsset: 1, RuleUnwrapAsset: 1, RuleLinkAsset: 1},
+		},
+		"pattern matches the whole value": {
+			opts:     []Option{AttributeRules(AttributeRule{Element: "a", Attribute: "data-asset-type", Pattern: "pod", Policy: AssetPolicy{Action: AssetUnwrap}})},
+			expected: `<body><p>Watch  and explore  or listen to <a href="https://www.ft.com/content/3f7c9a30-6d4b-4c58-9a0e-9d2f3c1b7e21" data-asset-type="podcast">the podcast</a>.</p></body>`,
+			rules:    map[string]int{RuleRemoveAsset: 2},
+		},
+		"rule without predicate": {
+			opts:     []Option{AttributeRules(AttributeRule{Element: "a", Attribute: "data-asset-type", Policy: AssetPolicy{Action: AssetUnwrap}})},
+			expected: `<body><p>Watch the interview and explore our tracker or listen to the podcast.</p></body>`,
+			rules:    map[string]int{RuleUnwrapAsset: 3},
+		},
+		"later options take precedence": {
+			opts: []Option{
+				AssetPolicies(map[string]AssetPolicy{"video": {Action: AssetUnwrap}}),
+				AttributeRules(AttributeRule{Element: "a", Attribute: "href", Match: func(value string) bool {
+					return strings.Contains(value, "/video/")
+				}, Policy: AssetPolicy{Action: AssetLink}}),
+			},
+			expected: `<body><p>Watch <a href="https://www.ft.com/content/5e1e4d1c-0cd3-4b9d-a5ba-1b2b6e2f3d0a">the interview</a> ` +
+				`and explore  or listen to <a href="https://www.ft.com/content/3f7c9a30-6d4b-4c58-9a0e-9d2f3c1b7e21" data-asset-type="podcast">the podcast</a>.</p></body>`,
+			rules: map[string]int{RuleLinkAsset: 1, RuleRemoveAsset: 1},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, report, err := Transform(body, test.opts...)
+			if err != nil {
+				t.Fatalf("unexpected transformation error: %s", err.Error())
+			}
+			if got != test.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s\n", test.expected, got)
+			}
+			for _, rule := range []string{RuleRemoveAsset, RuleUnwrapAsset, RuleLinkAsset} {
+				if report.Count(rule) != test.rules[rule] {
+					t.Fatalf("expected %d %s actions, got report:\n%s\n", test.rules[rule], rule, report)
+				}
+			}
+		})
+	}
+}
+
+func TestParseAttributeRules(t *testing.T) {
+	rules, err := ParseAttributeRules([]byte(`[
+		{"element": "a", "attribute": "data-asset-type", "values": ["video", "audio"], "policy": {"action": "link", "label": "Watch"}},
+		{"element": "a", "attribute": "href", "pattern": "https://ig\\.ft\\.com/.*", "policy": {"action": "unwrap"}}
+	]`))
+	if err != nil {
+		t.Fatalf("unexpected parse error: %s", err.Error())
+	}
+	body := `<body><p><a href="https://www.ft.com/video/5e1e4d1c-0cd3-4b9d-a5ba-1b2b6e2f3d0a" data-asset-type="video">video</a> ` +
+		`<a href="https://ig.ft.com/tracker/">tracker</a></p></body>`
+	expected := `<body><p><a href="https://www.ft.com/content/5e1e4d1c-0cd3-4b9d-a5ba-1b2b6e2f3d0a">Watch</a> tracker</p></body>`
+	got, _, err := Transform(body, AttributeRules(rules...))
+	if err != nil {
+		t.Fatalf("unexpected transformation error: %s", err.Error())
+	}
+	if got != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s\n", expected, got)
+	}
+
+	for name, config := range map[string]string{
+		"invalid json":      `{`,
+		"missing element":   `[{"attribute": "href", "policy": {"action": "remove"}}]`,
+		"unknown action":    `[{"element": "a", "attribute": "href", "policy": {"action": "hide"}}]`,
+		"invalid pattern":   `[{"element": "a", "attribute": "href", "pattern": "(", "policy": {"action": "remove"}}]`,
+		"invalid element":   `[{"element": "a[", "attribute": "href", "policy": {"action": "remove"}}]`,
+		"invalid attribute": `[{"element": "a", "attribute": "href]", "policy": {"action": "remove"}}]`,
+	} {
+		if _, err := ParseAttributeRules([]byte(config)); err == nil {
+			t.Errorf("%s: expected a parse error", name)
+		}
+	}
+}
+
+func TestAttributeRulesInvalidNames(t *testing.T) {
+	body := `<body><p><a href="https://www.ft.com/">link</a></p></body>`
+	got, report, err := Transform(body, AttributeRules(
+		AttributeRule{Element: "a[", Attribute: "href", Policy: AssetPolicy{Action: AssetRemove}},
+		AttributeRule{Element: "a", Attribute: "href='x'", Policy: AssetPolicy{Action: AssetRemove}},
+	))
+	if err != nil {
+		t.Fatalf("unexpected transformation error: %s", err.Error())
+	}
+	if got != body || report.Count(RuleRemoveAsset) != 0 {
+		t.Fatalf("expected the invalid rules to match nothing, got:\n%s\n", got)
+	}
+}
@@ -0,0 +1,199 @@
+package bodytransformer
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBatchWorkers is the number of workers used by TransformBatch when BatchOptions.Workers is not set
+const DefaultBatchWorkers = 4
+
+// Item is a single content body submitted for batch transformation
+type Item struct {
+	ID   string
+	Body string
+}
+
+// Result is the outcome of transforming a single Item. Err is set when the transformation of the item failed.
+type Result struct {
+	ID   string
+	Body string
+	Err  error
+}
+
+// BatchOptions configures TransformBatch
+type BatchOptions struct {
+	// Workers is the number of bodies transformed concurrently, DefaultBatchWorkers is used when it is not positive
+	Workers int
+	// Options customise the transformation of every item in the batch
+	Options []Option
+	// Stats, when set, is reset when the batch starts and updated with its throughput counters while it is running
+	Stats *BatchStats
+}
+
+// BatchStats holds the throughput counters of a batch transformation. It is safe to read while the batch is running,
+// but must not be shared by batches running at the same time.
+type BatchStats struct {
+	started   atomic.Int64
+	finished  atomic.Int64
+	processed atomic.Int64
+	failed    atomic.Int64
+}
+
+// Processed returns the number of items transformed so far, including the failed ones
+func (s *BatchStats) Processed() int64 {
+	return s.processed.Load()
+}
+
+// Failed returns the number of items whose transformation failed so far
+func (s *BatchStats) Failed() int64 {
+	return s.failed.Load()
+}
+
+// Throughput returns the number of items processed per second between the start and the end of the batch,
+// or until now while the batch is running
+func (s *BatchStats) Throughput() float64 {
+	started := s.started.Load()
+	if started == 0 {
+		return 0
+	}
+	end := time.Now()
+	if finished := s.finished.Load(); finished != 0 {
+		end = time.Unix(0, finished)
+	}
+	elapsed := end.Sub(time.Unix(0, started)).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Processed()) / elapsed
+}
+
+func (s *BatchStats) start() {
+	if s == nil {
+		return
+	}
+	s.processed.Store(0)
+	s.failed.Store(0)
+	s.finished.Store(0)
+	s.started.Store(time.Now().UnixNano())
+}
+
+func (s *BatchStats) finish() {
+	if s == nil {
+		return
+	}
+	s.finished.Store(time.Now().UnixNano())
+}
+
+func (s *BatchStats) record(err error) {
+	if s == nil {
+		return
+	}
+	s.processed.Add(1)
+	if err != nil {
+		s.failed.Add(1)
+	}
+}
+
+type batchJob struct {
+	item Item
+	slot chan Result
+}
+
+// TransformBatch transforms every item received from in using a bounded pool of workers.
+// Results are sent in the order the items were received. A failing item produces a Result with Err set and
+// does not abort the rest of the batch. The returned channel is closed once in is closed and all results are sent.
+// Once ctx is cancelled no more items are read from in and the returned channel is closed without waiting for the
+// consumer, so the results of the items already received may be dropped. The ones sent after the cancellation still
+// follow the input order.
+func TransformBatch(ctx context.Context, in <-chan Item, opts BatchOptions) <-chan Result {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultBatchWorkers
+	}
+	opts.Stats.start()
+
+	jobs := make(chan batchJob)
+	// pending holds one slot per dispatched item in input order, its capacity bounds the number of items in flight
+	pending := make(chan chan Result, workers)
+	out := make(chan Result)
+
+	go func() {
+		defer close(jobs)
+		defer close(pending)
+		for {
+			var item Item
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case item, ok = <-in:
+				if !ok {
+					return
+				}
+			}
+
+			job := batchJob{item: item, slot: make(chan Result, 1)}
+			select {
+			case <-ctx.Done():
+				return
+			case pending <- job.slot:
+			}
+			select {
+			case <-ctx.Done():
+				job.slot <- Result{ID: item.ID, Err: ctx.Err()}
+				return
+			case jobs <- job:
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				job.slot <- transformItem(ctx, job.item, opts)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(out)
+		sendResults(ctx, pending, out)
+		// the slots are buffered, the workers finish their jobs even when the results are not sent
+		wg.Wait()
+		opts.Stats.finish()
+	}()
+
+	return out
+}
+
+// sendResults sends the results of the pending slots in order, until they are all sent or ctx is cancelled
+func sendResults(ctx context.Context, pending <-chan chan Result, out chan<- Result) {
+	for slot := range pending {
+		var res Result
+		select {
+		case <-ctx.Done():
+			return
+		case res = <-slot:
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case out <- res:
+		}
+	}
+}
+
+func transformItem(ctx context.Context, item Item, opts BatchOptions) Result {
+	if err := ctx.Err(); err != nil {
+		return Result{ID: item.ID, Err: err}
+	}
+	body, _, err := TransformContext(ctx, item.Body, opts.Options...)
+	opts.Stats.record(err)
+	return Result{ID: item.ID, Body: body, Err: err}
+}
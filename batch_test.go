@@ -0,0 +1,181 @@
+package bodytransformer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/cm-body-transformer/filters"
+)
+
+func TestTransformBatch(t *testing.T) {
+	const count = 50
+	in := make(chan Item)
+	go func() {
+		defer close(in)
+		for i := 0; i < count; i++ {
+			body := fmt.Sprintf("<body><p>item %d</p></body>", i)
+			if i%10 == 0 {
+				body = "<body><p attr>invalid</p></body>"
+			}
+			in <- Item{ID: fmt.Sprint(i), Body: body}
+		}
+	}()
+
+	stats := &BatchStats{}
+	var got []Result
+	for res := range TransformBatch(context.Background(), in, BatchOptions{Workers: 8, Stats: stats}) {
+		got = append(got, res)
+	}
+
+	if len(got) != count {
+		t.Fatalf("expected %d results, got %d", count, len(got))
+	}
+	for i, res := range got {
+		if res.ID != fmt.Sprint(i) {
+			t.Fatalf("expected result %d to have id %d, got %s", i, i, res.ID)
+		}
+		if i%10 == 0 {
+			if res.Err == nil {
+				t.Errorf("expected error for item %d", i)
+			}
+			continue
+		}
+		if res.Err != nil {
+			t.Errorf("unexpected error for item %d: %v", i, res.Err)
+		}
+		expected := fmt.Sprintf("<body><p>item %d</p></body>", i)
+		if res.Body != expected {
+			t.Errorf("expected %q for item %d, got %q", expected, i, res.Body)
+		}
+	}
+	if stats.Processed() != count {
+		t.Errorf("expected %d processed items, got %d", count, stats.Processed())
+	}
+	if stats.Failed() != count/10 {
+		t.Errorf("expected %d failed items, got %d", count/10, stats.Failed())
+	}
+	if stats.Throughput() <= 0 {
+		t.Errorf("expected positive throughput, got %f", stats.Throughput())
+	}
+}
+
+func TestBatchStats(t *testing.T) {
+	run := func(stats *BatchStats, count int) {
+		in := make(chan Item, count)
+		for i := 0; i < count; i++ {
+			in <- Item{ID: fmt.Sprint(i), Body: "<body><p>text</p></body>"}
+		}
+		close(in)
+		for range TransformBatch(context.Background(), in, BatchOptions{Stats: stats}) {
+		}
+	}
+
+	stats := &BatchStats{}
+	run(stats, 5)
+	throughput := stats.Throughput()
+	time.Sleep(10 * time.Millisecond)
+	if stats.Throughput() != throughput {
+		t.Errorf("expected the throughput of a finished batch to be stable, got %f then %f", throughput, stats.Throughput())
+	}
+
+	run(stats, 3)
+	if stats.Processed() != 3 {
+		t.Errorf("expected the stats to be reset for a new batch, got %d processed items", stats.Processed())
+	}
+}
+
+// blockingImageResolver blocks until its context is done
+type blockingImageResolver struct{}
+
+func (blockingImageResolver) ResolveImage(ctx context.Context, _ string) (Image, error) {
+	<-ctx.Done()
+	return Image{}, ctx.Err()
+}
+
+func TestTransformBatchCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// the input channel is never closed, the batch has to stop on cancellation
+	in := make(chan Item)
+	out := TransformBatch(ctx, in, BatchOptions{Workers: 2, Options: []Option{Images(blockingImageResolver{})}})
+
+	in <- Item{ID: "1", Body: "<body><p>first</p></body>"}
+	res := <-out
+	if res.Err != nil || res.ID != "1" {
+		t.Fatalf("unexpected result before cancellation: %+v", res)
+	}
+
+	// the image sets block the transformation until the batch is cancelled
+	imageSet := `<body><content data-embedded="true" id="1" type="http://www.ft.com/ontology/content/ImageSet"/></body>`
+	in <- Item{ID: "2", Body: imageSet}
+	in <- Item{ID: "3", Body: imageSet}
+	cancel()
+
+	var got []Result
+	for res := range out {
+		got = append(got, res)
+	}
+	// the results of the items received before the cancellation may be dropped
+	if len(got) > 2 {
+		t.Fatalf("expected at most 2 results after cancellation, got %+v", got)
+	}
+	for i, res := range got {
+		if res.ID != fmt.Sprint(i+2) {
+			t.Errorf("expected result %d to have id %d, got %s", i, i+2, res.ID)
+		}
+		if !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("expected the cancellation error for item %s, got %v", res.ID, res.Err)
+		}
+	}
+}
+
+func TestTransformBatchCancelWithoutConsumer(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan Item, 4)
+	for i := 0; i < 4; i++ {
+		in <- Item{ID: fmt.Sprint(i), Body: "<body><p>text</p></body>"}
+	}
+	// the results are never read, the processed items wait to be sent when the batch is cancelled
+	stats := &BatchStats{}
+	TransformBatch(ctx, in, BatchOptions{Workers: 2, Stats: stats})
+	for stats.Processed() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the batch goroutines to exit after cancellation, %d are still running", runtime.NumGoroutine()-before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTransformBodyConcurrent(t *testing.T) {
+	bodyXML := readFile(t, "testdata/c0ac9d59-2285-4efc-b786-355a10ff3661/content.html")
+	expected := readFile(t, "testdata/c0ac9d59-2285-4efc-b786-355a10ff3661/expected.html")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := TransformBody(bodyXML)
+			if err != nil {
+				t.Errorf("unexpected transformation error: %v", err)
+				return
+			}
+			if got != expected {
+				t.Errorf("concurrent transformation returned unexpected body")
+			}
+			_ = filters.Apply(got, filters.DefaultContentFilters()...)
+		}()
+	}
+	wg.Wait()
+}
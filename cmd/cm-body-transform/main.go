@@ -0,0 +1,177 @@
+// Command cm-body-transform runs the body transformation on content bodies and prints the result.
+//
+// The bodies are read from the files given as arguments, from the content.html files found in the given directories,
+// or from stdin when no arguments are given. A body can be either raw bodyXML or a JSON document with a bodyXML or
+// body field, as returned by the document store.
+//
+//	cm-body-transform [-format body|text|report] [-keep table,img] [-strip aside] [file|dir|-]...
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	bodytransformer "github.com/Financial-Times/cm-body-transformer"
+	"github.com/Financial-Times/cm-body-transformer/filters"
+)
+
+const fixtureFileName = "content.html"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cm-body-transform", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "body", "output format: body (transformed body), text (plain text of the transformed body) or report (JSON report of the applied rules)")
+	keep := flags.String("keep", "", "comma separated list of element names to keep instead of stripping them")
+	strip := flags.String("strip", "", "comma separated list of element names to strip in addition to the default ones")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	switch *format {
+	case "body", "text", "report":
+	default:
+		fmt.Fprintf(stderr, "unknown output format %q\n", *format)
+		return 2
+	}
+
+	var opts []bodytransformer.Option
+	if names := splitList(*keep); len(names) > 0 {
+		opts = append(opts, bodytransformer.KeepElements(names...))
+	}
+	if names := splitList(*strip); len(names) > 0 {
+		opts = append(opts, bodytransformer.StripElements(names...))
+	}
+
+	inputs, err := collectInputs(flags.Args())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	status := 0
+	for _, input := range inputs {
+		if len(inputs) > 1 {
+			fmt.Fprintf(stdout, "==> %s <==\n", input)
+		}
+		if err := transformInput(input, *format, opts, stdin, stdout); err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", input, err)
+			status = 1
+		}
+	}
+	return status
+}
+
+// collectInputs expands the arguments to the list of inputs to transform, "-" stands for stdin
+func collectInputs(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return []string{"-"}, nil
+	}
+
+	var inputs []string
+	for _, arg := range args {
+		if arg == "-" {
+			inputs = append(inputs, arg)
+			continue
+		}
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			inputs = append(inputs, arg)
+			continue
+		}
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && d.Name() == fixtureFileName {
+				inputs = append(inputs, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return inputs, nil
+}
+
+func transformInput(input, format string, opts []bodytransformer.Option, stdin io.Reader, stdout io.Writer) error {
+	var data []byte
+	var err error
+	if input == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(input)
+	}
+	if err != nil {
+		return err
+	}
+
+	body, err := extractBody(data)
+	if err != nil {
+		return err
+	}
+
+	transformed, report, err := bodytransformer.Transform(body, opts...)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "text":
+		_, err = fmt.Fprintln(stdout, filters.Apply(transformed, filters.DefaultContentFilters()...))
+	case "report":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	default:
+		_, err = fmt.Fprintln(stdout, transformed)
+	}
+	return err
+}
+
+// extractBody returns the body to transform, taking it from the bodyXML or body fields if data is a JSON document
+func extractBody(data []byte) (string, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(trimmed, "{") {
+		return trimmed, nil
+	}
+
+	content := struct {
+		BodyXML string `json:"bodyXML"`
+		Body    string `json:"body"`
+	}{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		return "", fmt.Errorf("failed to decode JSON document: %w", err)
+	}
+	if content.BodyXML != "" {
+		return content.BodyXML, nil
+	}
+	if content.Body != "" {
+		return content.Body, nil
+	}
+	return "", errors.New("no body or body xml fields in JSON document")
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunStdin(t *testing.T) {
+	tests := map[string]struct {
+		args     []string
+		input    string
+		expected string
+	}{
+		"body": {
+			input:    `<body><p>text</p><table><tr><td>1</td></tr></table></body>`,
+			expected: "<body><p>text</p></body>\n",
+		},
+		"keep": {
+			args:     []string{"-keep", "table"},
+			input:    `<body><p>text</p><table><tr><td>1</td></tr></table></body>`,
+			expected: "<body><p>text</p><table><tr><td>1</td></tr></table></body>\n",
+		},
+		"strip": {
+			args:     []string{"-strip", "aside,figure"},
+			input:    `<body><p>text</p><aside>note</aside></body>`,
+			expected: "<body><p>text</p></body>\n",
+		},
+		"text": {
+			args:     []string{"-format", "text"},
+			input:    `<body><p>some <em>text</em></p><p>more</p></body>`,
+			expected: "some text more\n",
+		},
+		"json document": {
+			input:    `{"uuid": "1", "bodyXML": "<body><p>text</p><img src=\"x\"/></body>"}`,
+			expected: "<body><p>text</p></body>\n",
+		},
+		"json document with body": {
+			input:    `{"body": "<body><p>text</p></body>"}`,
+			expected: "<body><p>text</p></body>\n",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			status := run(test.args, strings.NewReader(test.input), &stdout, &stderr)
+			if status != 0 {
+				t.Fatalf("unexpected exit status %d: %s", status, stderr.String())
+			}
+			if stdout.String() != test.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s\n", test.expected, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunReport(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"-format", "report"}, strings.NewReader(`<body><p>text</p><img src="x"/></body>`), &stdout, &stderr)
+	if status != 0 {
+		t.Fatalf("unexpected exit status %d: %s", status, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"rule": "strip"`) || !strings.Contains(stdout.String(), `"path": "/body/img"`) {
+		t.Fatalf("unexpected report:\n%s", stdout.String())
+	}
+}
+
+func TestRunDirectory(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"../../testdata"}, nil, &stdout, &stderr)
+	if status != 0 {
+		t.Fatalf("unexpected exit status %d: %s", status, stderr.String())
+	}
+	fixtures, err := filepath.Glob("../../testdata/*/content.html")
+	if err != nil {
+		t.Fatalf("failed to list fixtures: %s", err.Error())
+	}
+	if got := strings.Count(stdout.String(), "==> "); got != len(fixtures) {
+		t.Fatalf("expected output for %d fixtures, got %d:\n%s", len(fixtures), got, stdout.String())
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"-format", "xml"}, strings.NewReader(""), &stdout, &stderr); status != 2 {
+		t.Errorf("expected exit status 2 for unknown format, got %d", status)
+	}
+	if status := run(nil, strings.NewReader(`{"uuid": "1"}`), &stdout, &stderr); status != 1 {
+		t.Errorf("expected exit status 1 for JSON without body, got %d", status)
+	}
+	if status := run([]string{"does-not-exist.html"}, nil, &stdout, &stderr); status != 1 {
+		t.Errorf("expected exit status 1 for missing file, got %d", status)
+	}
+}
@@ -0,0 +1,177 @@
+package bodytransformer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/beevik/etree"
+)
+
+// ContainerRule unwraps a layout container of the body, replacing it with some of its content
+type ContainerRule struct {
+	// Container is the path selecting the containers, e.g. //div[@class='n-content-layout']
+	Container string
+	// Hoist are the paths, relative to the container, selecting the elements put in its place in document order
+	Hoist []string
+	// DropAttributes are removed from the hoisted elements, e.g. internal class names
+	DropAttributes []string
+	// Wrapper, when set, is the element the hoisted elements are wrapped in
+	Wrapper string
+}
+
+// Built-in rules for the layout containers of the body schema
+var (
+	// LayoutSetContainer unwraps a set of layouts into its n-content-layout elements
+	LayoutSetContainer = ContainerRule{
+		Container:      "//div[@class='n-content-layout-set']",
+		Hoist:          []string{"./div[@class='n-content-layout']"},
+		DropAttributes: []string{"data-layout-set-name"},
+	}
+	// InfoBoxContainer unwraps an info box into an aside with its heading and content
+	InfoBoxContainer = ContainerRule{
+		Container: "//div[@class='n-content-info-box']",
+		Hoist: []string{
+			"./div[@class='n-content-info-box__heading']/*",
+			"./div[@class='n-content-info-box__content']/*",
+		},
+		DropAttributes: []string{"class"},
+		Wrapper:        "aside",
+	}
+	// ContentLayoutContainer unwraps a layout (card, info box...) into an aside with its heading and slot content
+	ContentLayoutContainer = ContainerRule{
+		Container: "//div[@class='n-content-layout']",
+		Hoist: []string{
+			"./div[@class='n-content-layout__container']/h1",
+			"./div[@class='n-content-layout__container']/h2",
+			"./div[@class='n-content-layout__container']/h3",
+			"./div[@class='n-content-layout__container']/h4",
+			"./div[@class='n-content-layout__container']/div[@class='n-content-layout__slot']/*",
+		},
+		DropAttributes: []string{"class", "data-slot-width", "data-layout-name", "data-layout-width"},
+		Wrapper:        "aside",
+	}
+)
+
+// DefaultContainerRules are the built-in container rules, in the order they have to be applied
+var DefaultContainerRules = []ContainerRule{LayoutSetContainer, InfoBoxContainer, ContentLayoutContainer}
+
+// UnwrapContainers applies the container rules, in order, to the transformed body.
+// Use DefaultContainerRules for the layout containers current content uses.
+// The transformation fails when a rule has an invalid path.
+func UnwrapContainers(rules ...ContainerRule) Option {
+	return func(cfg *config) {
+		for _, rule := range rules {
+			compiled, err := rule.compile()
+			if err != nil {
+				cfg.fail(err)
+				return
+			}
+			cfg.containerRules = append(cfg.containerRules, compiled)
+		}
+	}
+}
+
+// compiledContainerRule is a container rule with its paths compiled
+type compiledContainerRule struct {
+	ContainerRule
+	container etree.Path
+	hoist     []etree.Path
+}
+
+func (rule ContainerRule) compile() (compiledContainerRule, error) {
+	compiled := compiledContainerRule{ContainerRule: rule}
+	var err error
+	if compiled.container, err = compilePath(rule.Container); err != nil {
+		return compiledContainerRule{}, fmt.Errorf("invalid container path %q: %w", rule.Container, err)
+	}
+	for _, hoist := range rule.Hoist {
+		path, err := compilePath(hoist)
+		if err != nil {
+			return compiledContainerRule{}, fmt.Errorf("invalid hoist path %q of container %q: %w", hoist, rule.Container, err)
+		}
+		compiled.hoist = append(compiled.hoist, path)
+	}
+	return compiled, nil
+}
+
+// compilePath compiles an etree path, returning an error instead of panicking for the malformed ones etree does not check
+func compilePath(path string) (p etree.Path, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("etree: malformed path: %v", r)
+		}
+	}()
+	return etree.CompilePath(path)
+}
+
+// unwrapContainers replaces every container selected by the rules with its hoisted elements
+func unwrapContainers(doc *etree.Document, rules []compiledContainerRule, report *Report) {
+	for _, rule := range rules {
+		// the containers are selected once, so that wrappers and hoisted elements matching the rule are not unwrapped
+		for _, container := range doc.FindElementsPath(rule.container) {
+			// containers nested in a container whose content was dropped are detached
+			if !isDescendant(container, &doc.Element) {
+				continue
+			}
+			report.add(RuleUnwrapContainer, container)
+
+			parent, index := container.Parent(), container.Index()
+			hoisted := hoistedElements(container, rule.hoist)
+			if rule.Wrapper != "" && len(hoisted) > 0 {
+				wrapper := etree.NewElement(rule.Wrapper)
+				parent.InsertChildAt(index, wrapper)
+				parent, index = wrapper, 0
+			}
+			for _, el := range hoisted {
+				for _, key := range rule.DropAttributes {
+					el.RemoveAttr(key)
+				}
+				parent.InsertChildAt(index, el)
+				index++
+			}
+			container.Parent().RemoveChild(container)
+		}
+	}
+}
+
+// hoistedElements returns the elements of the container selected by the paths, in document order.
+// Elements nested in other selected elements are hoisted with them.
+func hoistedElements(container *etree.Element, paths []etree.Path) []*etree.Element {
+	order := map[*etree.Element]int{}
+	var index func(el *etree.Element)
+	index = func(el *etree.Element) {
+		order[el] = len(order)
+		for _, child := range el.ChildElements() {
+			index(child)
+		}
+	}
+	index(container)
+
+	selected := map[*etree.Element]bool{}
+	for _, path := range paths {
+		for _, el := range container.FindElementsPath(path) {
+			selected[el] = true
+		}
+	}
+
+	var hoisted []*etree.Element
+	for el := range selected {
+		if el == container || hasSelectedAncestor(el, container, selected) {
+			continue
+		}
+		hoisted = append(hoisted, el)
+	}
+	sort.Slice(hoisted, func(i, j int) bool {
+		return order[hoisted[i]] < order[hoisted[j]]
+	})
+	return hoisted
+}
+
+func hasSelectedAncestor(el, container *etree.Element, selected map[*etree.Element]bool) bool {
+	for p := el.Parent(); p != nil && p != container; p = p.Parent() {
+		if selected[p] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package bodytransformer
+
+import "testing"
+
+func TestUnwrapContainers(t *testing.T) {
+	tests := map[string]struct {
+		body     string
+		rules    []ContainerRule
+		expected string
+		actions  int
+	}{
+		"custom rule": {
+			body: `<body><div class="box"><div class="title"><h2 class="internal">Title</h2></div><p class="internal" id="p1">Text</p><span>dropped</span></div><p>After</p></body>`,
+			rules: []ContainerRule{{
+				Container:      "//div[@class='box']",
+				Hoist:          []string{"./div[@class='title']/h2", "./p"},
+				DropAttributes: []string{"class"},
+			}},
+			expected: `<body><h2>Title</h2><p id="p1">Text</p><p>After</p></body>`,
+			actions:  1,
+		},
+		"wrapper and document order": {
+			body: `<body><div class="box"><p>First</p><h2>Second</h2><p>Third <em>nested</em></p></div></body>`,
+			rules: []ContainerRule{{
+				Container: "//div[@class='box']",
+				Hoist:     []string{"./h2", "./p", ".//em"},
+				Wrapper:   "aside",
+			}},
+			expected: `<body><aside><p>First</p><h2>Second</h2><p>Third <em>nested</em></p></aside></body>`,
+			actions:  1,
+		},
+		"nothing hoisted": {
+			body:     `<body><div class="box"><p>Text</p></div><p>After</p></body>`,
+			rules:    []ContainerRule{{Container: "//div[@class='box']", Wrapper: "aside"}},
+			expected: `<body><p>After</p></body>`,
+			actions:  1,
+		},
+		"wrapper matching the container": {
+			body:     `<body><aside><p>Text</p><aside><p>Dropped</p></aside></aside></body>`,
+			rules:    []ContainerRule{{Container: "//aside", Hoist: []string{"./p"}, Wrapper: "aside"}},
+			expected: `<body><aside><p>Text</p></aside></body>`,
+			actions:  1,
+		},
+		"hoisted nested container": {
+			body:     `<body><div class="box"><p>A</p><div class="box"><p>B</p></div></div></body>`,
+			rules:    []ContainerRule{{Container: "//div[@class='box']", Hoist: []string{"./*"}, Wrapper: "section"}},
+			expected: `<body><section><p>A</p><section><p>B</p></section></section></body>`,
+			actions:  2,
+		},
+		"built-in layout set and layouts": {
+			body: `<body><div class="n-content-layout-set" data-layout-set-name="pair">` +
+				`<div class="n-content-layout" data-layout-name="card" data-layout-width="inset-left"><div class="n-content-layout__container"><h3>One</h3>` +
+				`<div class="n-content-layout__slot" data-slot-width="true"><p>First card</p></div></div></div>` +
+				`<div class="n-content-layout" data-layout-name="card"><div class="n-content-layout__container">` +
+				`<div class="n-content-layout__slot"><p>Second card</p></div></div></div></div></body>`,
+			rules:    DefaultContainerRules,
+			expected: `<body><aside><h3>One</h3><p>First card</p></aside><aside><p>Second card</p></aside></body>`,
+			actions:  3,
+		},
+		"built-in info box": {
+			body: `<body><div class="n-content-info-box"><div class="n-content-info-box__heading"><h4 class="n-content-info-box__title">Key facts</h4></div>` +
+				`<div class="n-content-info-box__content"><ul><li>One</li></ul></div></div></body>`,
+			rules:    DefaultContainerRules,
+			expected: `<body><aside><h4>Key facts</h4><ul><li>One</li></ul></aside></body>`,
+			actions:  1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, report, err := Transform(test.body, UnwrapContainers(test.rules...))
+			if err != nil {
+				t.Fatalf("unexpected transformation error: %s", err.Error())
+			}
+			if got != test.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s\n", test.expected, got)
+			}
+			if report.Count(RuleUnwrapContainer) != test.actions {
+				t.Fatalf("expected %d unwrap actions, got report:\n%s\n", test.actions, report)
+			}
+		})
+	}
+}
+
+func TestUnwrapContainersInvalidPaths(t *testing.T) {
+	for name, rule := range map[string]ContainerRule{
+		"container": {Container: "//div[", Hoist: []string{"./p"}},
+		"hoist":     {Container: "//div", Hoist: []string{"./p", "./p[@class='x"}},
+	} {
+		_, _, err := Transform(`<body><div><p>Text</p></div></body>`, UnwrapContainers(rule))
+		if err == nil {
+			t.Errorf("%s: expected an invalid path error", name)
+		}
+	}
+}
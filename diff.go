@@ -0,0 +1,294 @@
+package bodytransformer
+
+import (
+	"crypto/sha256"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// Kinds of differences reported by Diff
+const (
+	DiffParse     = "parse"
+	DiffMissing   = "missing"
+	DiffExtra     = "extra"
+	DiffAttribute = "attribute"
+	DiffText      = "text"
+)
+
+// DiffOptions configures the comparison done by Diff
+type DiffOptions struct {
+	// IgnoreAttributes lists the names of attributes that are not compared, with their namespace prefix as they are
+	// written in the body and in the paths of the differences, e.g. xlink:href
+	IgnoreAttributes []string
+	// KeepWhitespace makes Diff compare text exactly instead of collapsing whitespace runs and trimming text
+	KeepWhitespace bool
+}
+
+// Difference is a semantic difference between two bodies.
+// Path locates the node in the body where the difference was found, e.g. /body/p[2]/@class or /body/p[3]/text()[1].
+// A and B hold the values of the node in each of the bodies, empty when the node is missing from one of them.
+type Difference struct {
+	Path string
+	Kind string
+	A    string
+	B    string
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s %s: %q != %q", d.Kind, d.Path, d.A, d.B)
+}
+
+// Diff compares two bodies as XML trees and returns their semantic differences.
+// The order of attributes, self-closing or explicit end tags, entities or literal characters, comments and
+// whitespace-only text are ignored. Unless opts.KeepWhitespace is set, whitespace runs in text are collapsed and
+// the text is trimmed before comparison, only ASCII whitespace counts so that non-breaking spaces are reported.
+// Bodies are parsed leniently, so that the html-unescaped output of TransformBody can be compared, and a body that
+// can't be parsed is reported as a DiffParse difference.
+func Diff(a, b string, opts DiffOptions) []Difference {
+	docA, errA := parseForDiff(a)
+	docB, errB := parseForDiff(b)
+	if errA != nil || errB != nil {
+		d := Difference{Path: "/", Kind: DiffParse}
+		if errA != nil {
+			d.A = errA.Error()
+		}
+		if errB != nil {
+			d.B = errB.Error()
+		}
+		return []Difference{d}
+	}
+
+	c := differ{opts: opts, signatures: map[*etree.Element]string{}}
+	c.compareChildren("", &docA.Element, &docB.Element)
+	return c.diffs
+}
+
+func parseForDiff(body string) (*etree.Document, error) {
+	doc := etree.NewDocument()
+	// the output of TransformBody is html-unescaped and can contain bare "&" characters
+	doc.ReadSettings.Permissive = true
+	doc.ReadSettings.Entity = xml.HTMLEntity
+	if err := doc.ReadFromString(body); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+type differ struct {
+	opts  DiffOptions
+	diffs []Difference
+	// signatures caches the signature of the compared elements
+	signatures map[*etree.Element]string
+}
+
+// diffNode is a child of an element relevant to the comparison, either an element or merged text
+type diffNode struct {
+	el   *etree.Element
+	text string
+	path string
+}
+
+func (n diffNode) key() string {
+	if n.el != nil {
+		return n.el.Tag
+	}
+	return "#text"
+}
+
+func (c *differ) add(path, kind, a, b string) {
+	c.diffs = append(c.diffs, Difference{Path: path, Kind: kind, A: a, B: b})
+}
+
+// compareChildren aligns the children of a and b on their content first, so that an inserted or removed node is
+// reported once, and then the remaining ones on their tag names, so that a changed node is compared in detail
+func (c *differ) compareChildren(path string, a, b *etree.Element) {
+	nodesA, nodesB := c.nodes(path, a), c.nodes(path, b)
+
+	var gapA, gapB []diffNode
+	compareGap := func() {
+		sameKey := func(x, y diffNode) bool { return x.key() == y.key() }
+		for _, p := range align(gapA, gapB, sameKey) {
+			switch {
+			case p.a == nil:
+				c.add(p.b.path, DiffExtra, "", p.b.describe())
+			case p.b == nil:
+				c.add(p.a.path, DiffMissing, p.a.describe(), "")
+			default:
+				c.compareNodes(*p.a, *p.b)
+			}
+		}
+		gapA, gapB = nil, nil
+	}
+	sameContent := func(x, y diffNode) bool { return c.signature(x) == c.signature(y) }
+	for _, p := range align(nodesA, nodesB, sameContent) {
+		switch {
+		case p.a == nil:
+			gapB = append(gapB, *p.b)
+		case p.b == nil:
+			gapA = append(gapA, *p.a)
+		default:
+			// equal nodes have no differences
+			compareGap()
+		}
+	}
+	compareGap()
+}
+
+// alignedPair is a node of each side aligned by align, or a node of one side only when the other one is nil
+type alignedPair struct {
+	a, b *diffNode
+}
+
+// align returns the longest common subsequence of a and b under equal, with the nodes outside of it in order
+func align(a, b []diffNode, equal func(x, y diffNode) bool) []alignedPair {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if equal(a[i], b[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var pairs []alignedPair
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && equal(a[i], b[j]) && lcs[i][j] == lcs[i+1][j+1]+1:
+			pairs = append(pairs, alignedPair{a: &a[i], b: &b[j]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			pairs = append(pairs, alignedPair{a: &a[i]})
+			i++
+		default:
+			pairs = append(pairs, alignedPair{b: &b[j]})
+			j++
+		}
+	}
+	return pairs
+}
+
+// signature identifies the content of a node as it is compared: equal signatures mean no differences
+func (c *differ) signature(n diffNode) string {
+	if n.el == nil {
+		return "#text " + n.text
+	}
+	if sig, ok := c.signatures[n.el]; ok {
+		return sig
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%q", n.el.Tag)
+	attrs := c.attributes(n.el)
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(h, " %q=%q", k, attrs[k])
+	}
+	for _, child := range c.nodes("", n.el) {
+		fmt.Fprintf(h, " %q", c.signature(child))
+	}
+	sig := string(h.Sum(nil))
+	c.signatures[n.el] = sig
+	return sig
+}
+
+func (c *differ) compareNodes(a, b diffNode) {
+	if a.el == nil {
+		if a.text != b.text {
+			c.add(a.path, DiffText, a.text, b.text)
+		}
+		return
+	}
+	c.compareAttributes(a.path, a.el, b.el)
+	c.compareChildren(a.path, a.el, b.el)
+}
+
+func (c *differ) compareAttributes(path string, a, b *etree.Element) {
+	attrsA, attrsB := c.attributes(a), c.attributes(b)
+	keys := make([]string, 0, len(attrsA)+len(attrsB))
+	for k := range attrsA {
+		keys = append(keys, k)
+	}
+	for k := range attrsB {
+		if _, ok := attrsA[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		valA, okA := attrsA[k]
+		valB, okB := attrsB[k]
+		switch {
+		case !okA:
+			c.add(path+"/@"+k, DiffExtra, "", valB)
+		case !okB:
+			c.add(path+"/@"+k, DiffMissing, valA, "")
+		case valA != valB:
+			c.add(path+"/@"+k, DiffAttribute, valA, valB)
+		}
+	}
+}
+
+func (c *differ) attributes(el *etree.Element) map[string]string {
+	attrs := map[string]string{}
+	for _, attr := range el.Attr {
+		if contains(c.opts.IgnoreAttributes, attr.FullKey()) {
+			continue
+		}
+		attrs[attr.FullKey()] = attr.Value
+	}
+	return attrs
+}
+
+// nodes returns the child elements and the merged, normalised text nodes of el with their paths
+func (c *differ) nodes(path string, el *etree.Element) []diffNode {
+	var nodes []diffNode
+	counts := map[string]int{}
+	var text strings.Builder
+	flush := func() {
+		t := text.String()
+		text.Reset()
+		if !c.opts.KeepWhitespace {
+			// only the ASCII whitespace is collapsed, a non-breaking space is a difference
+			t = strings.Join(strings.FieldsFunc(t, isASCIISpace), " ")
+		}
+		if strings.TrimFunc(t, isASCIISpace) == "" {
+			return
+		}
+		counts["#text"]++
+		nodes = append(nodes, diffNode{text: t, path: fmt.Sprintf("%s/text()[%d]", path, counts["#text"])})
+	}
+
+	for _, token := range el.Child {
+		switch t := token.(type) {
+		case *etree.CharData:
+			text.WriteString(t.Data)
+		case *etree.Element:
+			flush()
+			counts[t.Tag]++
+			nodes = append(nodes, diffNode{el: t, path: fmt.Sprintf("%s/%s[%d]", path, t.Tag, counts[t.Tag])})
+		}
+	}
+	flush()
+	return nodes
+}
+
+func (n diffNode) describe() string {
+	if n.el == nil {
+		return n.text
+	}
+	return "<" + n.el.Tag + ">"
+}
@@ -0,0 +1,99 @@
+package bodytransformer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := map[string]struct {
+		a        string
+		b        string
+		opts     DiffOptions
+		expected []Difference
+	}{
+		"cosmetic differences": {
+			a: `<body><p class="x" id="1">Marks &amp; Spencer&#8217;s results</p>
+				<ft-content type="t" url="u"></ft-content><!-- comment --></body>`,
+			b: `<body><p id="1" class="x">Marks &amp; Spencer’s results</p><ft-content url="u" type="t"/></body>`,
+		},
+		"insignificant whitespace": {
+			a: "<body>\n  <p>some   text\n</p>\n</body>",
+			b: "<body><p>some text</p></body>",
+		},
+		"non-breaking space": {
+			a: `<body><p>Marks &amp; Spencer&nbsp;results</p></body>`,
+			b: `<body><p>Marks &amp; Spencer results</p></body>`,
+			expected: []Difference{
+				{Path: "/body[1]/p[1]/text()[1]", Kind: DiffText, A: "Marks & Spencer\u00a0results", B: "Marks & Spencer results"},
+			},
+		},
+		"significant whitespace": {
+			a:    "<body><p>some   text</p></body>",
+			b:    "<body><p>some text</p></body>",
+			opts: DiffOptions{KeepWhitespace: true},
+			expected: []Difference{
+				{Path: "/body[1]/p[1]/text()[1]", Kind: DiffText, A: "some   text", B: "some text"},
+			},
+		},
+		"text": {
+			a: `<body><p>first</p><p>second <em>one</em></p></body>`,
+			b: `<body><p>first</p><p>2nd <em>one</em></p></body>`,
+			expected: []Difference{
+				{Path: "/body[1]/p[2]/text()[1]", Kind: DiffText, A: "second", B: "2nd"},
+			},
+		},
+		"attributes": {
+			a: `<body><a href="x" title="t">link</a></body>`,
+			b: `<body><a href="y" rel="nofollow">link</a></body>`,
+			expected: []Difference{
+				{Path: "/body[1]/a[1]/@href", Kind: DiffAttribute, A: "x", B: "y"},
+				{Path: "/body[1]/a[1]/@rel", Kind: DiffExtra, B: "nofollow"},
+				{Path: "/body[1]/a[1]/@title", Kind: DiffMissing, A: "t"},
+			},
+		},
+		"ignored attributes": {
+			a:    `<body><a href="x" title="t">link</a></body>`,
+			b:    `<body><a href="x">link</a></body>`,
+			opts: DiffOptions{IgnoreAttributes: []string{"title"}},
+		},
+		"ignored namespaced attributes": {
+			a:    `<body><a xlink:href="x" href="y">link</a></body>`,
+			b:    `<body><a href="y">link</a></body>`,
+			opts: DiffOptions{IgnoreAttributes: []string{"xlink:href"}},
+		},
+		"missing and extra elements": {
+			a: `<body><p>one</p><p>two</p><p>three</p></body>`,
+			b: `<body><p>one</p><p>three</p><h2>four</h2></body>`,
+			expected: []Difference{
+				{Path: "/body[1]/p[2]", Kind: DiffMissing, A: "<p>"},
+				{Path: "/body[1]/h2[1]", Kind: DiffExtra, B: "<h2>"},
+			},
+		},
+		"inserted sibling with the same tag": {
+			a: `<body><p>one</p><p>two</p><p>three <em>3</em></p></body>`,
+			b: `<body><p>one</p><p>new</p><p>two</p><p>three <em>three</em></p></body>`,
+			expected: []Difference{
+				{Path: "/body[1]/p[2]", Kind: DiffExtra, B: "<p>"},
+				{Path: "/body[1]/p[3]/em[1]/text()[1]", Kind: DiffText, A: "3", B: "three"},
+			},
+		},
+		"parse error": {
+			a: `<body><p>text</p`,
+			b: `<body><p>text</p></body>`,
+			expected: []Difference{
+				{Path: "/", Kind: DiffParse, A: "XML syntax error on line 1: unexpected EOF"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			got := Diff(test.a, test.b, test.opts)
+			if !reflect.DeepEqual(test.expected, got) {
+				t.Fatalf("expected:\n%v\ngot:\n%v\n", test.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package bodytransformer
+
+import (
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// ConvertBigNumbers keeps the big numbers of the body instead of stripping them, as a paragraph with the headline
+// figure in strong and the description in em, e.g. <p><strong>$82bn</strong> <em>Tether market value</em></p>
+func ConvertBigNumbers() Option {
+	return func(cfg *config) {
+		cfg.convertBigNumbers = true
+		KeepElements("big-number")(cfg)
+	}
+}
+
+// ConvertPullQuotes keeps the pull quotes of the body instead of stripping them, as a blockquote with the quote
+// paragraphs and a footer citing the source. The pull quote image is dropped.
+func ConvertPullQuotes() Option {
+	return func(cfg *config) {
+		cfg.convertPullQuotes = true
+		KeepElements("pull-quote")(cfg)
+	}
+}
+
+func convertBigNumbers(doc *etree.Document, report *Report) {
+	for _, el := range doc.FindElements("//big-number") {
+		p := etree.NewElement("p")
+		if headline := el.SelectElement("big-number-headline"); headline != nil && elementText(headline) != "" {
+			addInlineContent(p.CreateElement("strong"), headline)
+		}
+		if intro := el.SelectElement("big-number-intro"); intro != nil && elementText(intro) != "" {
+			if len(p.Child) > 0 {
+				p.CreateText(" ")
+			}
+			addInlineContent(p.CreateElement("em"), intro)
+		}
+		// a big number without text is removed
+		if len(p.Child) == 0 {
+			removeElement(el, RuleConvertBigNumber, report)
+			continue
+		}
+		report.add(RuleConvertBigNumber, el)
+		replaceElement(el, p)
+	}
+}
+
+func convertPullQuotes(doc *etree.Document, report *Report) {
+	for _, el := range doc.FindElements("//pull-quote") {
+		quote := etree.NewElement("blockquote")
+		if text := el.SelectElement("pull-quote-text"); text != nil {
+			if hasBlockDescendant(text) {
+				for _, child := range text.ChildElements() {
+					quote.AddChild(child)
+				}
+			} else if elementText(text) != "" {
+				addInlineContent(quote.CreateElement("p"), text)
+			}
+		}
+		if source := el.SelectElement("pull-quote-source"); source != nil && elementText(source) != "" {
+			addInlineContent(quote.CreateElement("footer").CreateElement("cite"), source)
+		}
+		// a pull quote without text is removed
+		if len(quote.Child) == 0 {
+			removeElement(el, RuleConvertPullQuote, report)
+			continue
+		}
+		report.add(RuleConvertPullQuote, el)
+		replaceElement(el, quote)
+	}
+}
+
+// addInlineContent moves the content of src to dst, replacing the paragraphs in src with their content and trimming
+// the whitespace around it
+func addInlineContent(dst, src *etree.Element) {
+	var tokens []etree.Token
+	for _, token := range src.Child {
+		if el, ok := token.(*etree.Element); ok && el.Tag == "p" {
+			if len(tokens) > 0 {
+				tokens = append(tokens, etree.NewText(" "))
+			}
+			tokens = append(tokens, el.Child...)
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	for i, token := range tokens {
+		if text, ok := token.(*etree.CharData); ok {
+			if i == 0 {
+				text.Data = strings.TrimLeft(text.Data, " \t\r\n")
+			}
+			if i == len(tokens)-1 {
+				text.Data = strings.TrimRight(text.Data, " \t\r\n")
+			}
+		}
+		dst.AddChild(token)
+	}
+}
+
+// replaceElement puts replacement in the place of el
+func replaceElement(el, replacement *etree.Element) {
+	parent := el.Parent()
+	parent.InsertChildAt(el.Index(), replacement)
+	parent.RemoveChild(el)
+}
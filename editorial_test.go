@@ -0,0 +1,76 @@
+package bodytransformer
+
+import "testing"
+
+func TestConvertBigNumbers(t *testing.T) {
+	tests := map[string]struct {
+		body     string
+		expected string
+	}{
+		"headline and intro": {
+			body: `<body><big-number><big-number-headline>$82bn</big-number-headline>` +
+				`<big-number-intro><p>Market value of <a href="https://www.ft.com/content/1">Tether</a></p></big-number-intro></big-number></body>`,
+			expected: `<body><p><strong>$82bn</strong> <em>Market value of <a href="https://www.ft.com/content/1">Tether</a></em></p></body>`,
+		},
+		"headline only": {
+			body:     `<body><big-number><big-number-headline> 60% </big-number-headline><big-number-intro/></big-number></body>`,
+			expected: `<body><p><strong>60%</strong></p></body>`,
+		},
+		"empty big number": {
+			body:     `<body><p>Text</p><big-number><big-number-headline/></big-number></body>`,
+			expected: `<body><p>Text</p></body>`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, report, err := Transform(test.body, ConvertBigNumbers())
+			if err != nil {
+				t.Fatalf("unexpected transformation error: %s", err.Error())
+			}
+			if got != test.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s\n", test.expected, got)
+			}
+			if report.Count(RuleConvertBigNumber) != 1 || report.Count(RuleStrip) != 0 {
+				t.Fatalf("expected the big number to be converted, got report:\n%s\n", report)
+			}
+		})
+	}
+}
+
+func TestConvertPullQuotes(t *testing.T) {
+	tests := map[string]struct {
+		body     string
+		expected string
+	}{
+		"text and source": {
+			body: `<body><pull-quote><pull-quote-text><p>It’s better to beg for forgiveness</p></pull-quote-text>` +
+				`<pull-quote-image><content data-embedded="true" id="1" type="http://www.ft.com/ontology/content/ImageSet"/></pull-quote-image>` +
+				`<pull-quote-source>John Reed Stark</pull-quote-source></pull-quote></body>`,
+			expected: `<body><blockquote><p>It’s better to beg for forgiveness</p><footer><cite>John Reed Stark</cite></footer></blockquote></body>`,
+		},
+		"inline text without source": {
+			body:     `<body><pull-quote><pull-quote-text>Period.</pull-quote-text><pull-quote-source/></pull-quote></body>`,
+			expected: `<body><blockquote><p>Period.</p></blockquote></body>`,
+		},
+		"empty pull quote": {
+			body:     `<body><p>Text</p><pull-quote><pull-quote-image/></pull-quote></body>`,
+			expected: `<body><p>Text</p></body>`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, report, err := Transform(test.body, ConvertPullQuotes())
+			if err != nil {
+				t.Fatalf("unexpected transformation error: %s", err.Error())
+			}
+			if got != test.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s\n", test.expected, got)
+			}
+			if report.Count(RuleConvertPullQuote) != 1 || report.Count(RuleStrip) != 0 {
+				t.Fatalf("expected the pull quote to be converted, got report:\n%s\n", report)
+			}
+		})
+	}
+}
@@ -29,3 +29,11 @@ func ExampleDedupSpaces() {
 	fmt.Println(result)
 	// Output: testing	dedup
 }
+
+func ExampleExcerpt() {
+	body := `<body><p>By Jane Smith in London |</p><p>Shares rose 2.5 per cent. “We are confident,” the chief executive said. “The outlook is strong.”</p></body>`
+	text := filters.Apply(body, filters.DefaultContentFilters()...)
+	result := filters.Excerpt(text, filters.ExcerptOptions{MaxChars: 80, Ellipsis: "…"})
+	fmt.Println(result)
+	// Output: Shares rose 2.5 per cent. “We are confident,” the chief executive said.
+}
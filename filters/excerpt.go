@@ -0,0 +1,187 @@
+package filters
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultSkipPatterns match the bylines and image captions that are skipped at the start of an excerpt
+var DefaultSkipPatterns = []*regexp.Regexp{
+	// bylines ending with a full stop or a separator, e.g. "By Jane Smith and John Doe in London |"
+	regexp.MustCompile(`^By \p{Lu}[\p{L}.'’-]*(?: \p{Lu}[\p{L}.'’-]*)*(?:,? and \p{Lu}[\p{L}.'’-]*(?: \p{Lu}[\p{L}.'’-]*)*)*(?: in \p{Lu}\p{L}*(?: \p{Lu}\p{L}*)*)? ?[.|—–]\s*`),
+	// captions ending with a picture credit, e.g. "Jerome Powell at a press conference © AFP/Getty Images", only when
+	// the credit ends the caption: it is followed by the capitalised start of the running text or by nothing
+	regexp.MustCompile(`^([^.!?©]{0,200}?©\s*[\p{L}&.]+(?: Images)?(?:\s*/\s*[\p{L}&.]+(?: Images)?)*)(?:\s+\p{Lu}|\s*$)`),
+	// labelled captions ending with a full stop or a separator, e.g. "Photo: Reuters."
+	regexp.MustCompile(`^(?:Photo|Picture|Image|Graphic|Source)s?:[^.!?|]*[.!?|]\s*`),
+}
+
+// ExcerptOptions configures Excerpt. Zero limits are not applied.
+type ExcerptOptions struct {
+	// MaxChars is the maximum number of characters (runes) of the excerpt, including the ellipsis
+	MaxChars int
+	// MaxWords is the maximum number of words of the excerpt
+	MaxWords int
+	// MaxSentences is the maximum number of sentences of the excerpt
+	MaxSentences int
+	// Ellipsis is appended to the excerpt when the text was cut inside a sentence, e.g. "…"
+	Ellipsis string
+	// SkipPatterns match the leading parts of the text that are skipped, DefaultSkipPatterns are used when nil.
+	// When a pattern has a capturing group, only the text matched by the first group is skipped.
+	// Use an empty slice to skip nothing.
+	SkipPatterns []*regexp.Regexp
+}
+
+// Excerpt returns the beginning of a plain text, as produced by applying DefaultContentFilters, within the limits
+// set in opts. The text is cut after the last whole sentence that fits, or after the last whole word when not even the
+// first sentence fits. The excerpt never ends inside an open quotation, unless the text opens with a quotation that
+// does not close within the limits: it is then cut after the last whole word that fits.
+func Excerpt(text string, opts ExcerptOptions) string {
+	text = skipLeading(strings.TrimSpace(text), opts.SkipPatterns)
+
+	var excerpt []string
+	var quotes quoteState
+	balanced := 0
+	for _, sentence := range Sentences(text) {
+		candidate := append(excerpt, sentence)
+		if !opts.fits(candidate, len(candidate), text) {
+			break
+		}
+		excerpt = candidate
+		quotes.scan(sentence)
+		if quotes.balanced() {
+			balanced = len(excerpt)
+		}
+	}
+	excerpt = excerpt[:balanced]
+
+	if len(excerpt) == 0 {
+		excerpt = opts.firstWords(text)
+	}
+
+	result := strings.Join(excerpt, " ")
+	if result == text || result == "" || opts.Ellipsis == "" || endsSentence(result) {
+		return result
+	}
+	return strings.TrimRight(result, " ,;:—–-") + opts.Ellipsis
+}
+
+// firstWords returns the longest run of words from the start of text, within its first MaxSentences sentences, that
+// fits in the limits and does not end inside an open quotation, or the longest run that fits when the quotation opened
+// by the first word does not close within the limits
+func (opts ExcerptOptions) firstWords(text string) []string {
+	limited := text
+	if sentences := Sentences(text); opts.MaxSentences > 0 && len(sentences) > opts.MaxSentences {
+		limited = strings.Join(sentences[:opts.MaxSentences], " ")
+	}
+
+	var words []string
+	var quotes quoteState
+	balanced := 0
+	for _, word := range strings.Fields(limited) {
+		candidate := append(words, word)
+		if !opts.fits(candidate, 1, text) {
+			break
+		}
+		words = candidate
+		quotes.scan(word)
+		if quotes.balanced() {
+			balanced = len(words)
+		}
+	}
+	if balanced == 0 {
+		return words
+	}
+	return words[:balanced]
+}
+
+// fits reports whether the parts joined with spaces fit in the limits.
+// The ellipsis is counted when the parts do not make up the whole text.
+func (opts ExcerptOptions) fits(parts []string, sentences int, text string) bool {
+	if opts.MaxSentences > 0 && sentences > opts.MaxSentences {
+		return false
+	}
+	joined := strings.Join(parts, " ")
+	if opts.MaxWords > 0 && CountWords(joined) > opts.MaxWords {
+		return false
+	}
+	if opts.MaxChars > 0 {
+		chars := utf8.RuneCountInString(joined)
+		if joined != text {
+			chars += utf8.RuneCountInString(opts.Ellipsis)
+		}
+		if chars > opts.MaxChars {
+			return false
+		}
+	}
+	return true
+}
+
+func skipLeading(text string, patterns []*regexp.Regexp) string {
+	if patterns == nil {
+		patterns = DefaultSkipPatterns
+	}
+	for skipped := true; skipped; {
+		skipped = false
+		for _, p := range patterns {
+			loc := p.FindStringSubmatchIndex(text)
+			if loc == nil {
+				continue
+			}
+			end := loc[1]
+			if len(loc) > 2 && loc[3] >= 0 {
+				end = loc[3]
+			}
+			if end > 0 {
+				text = strings.TrimSpace(text[end:])
+				skipped = true
+			}
+		}
+	}
+	return text
+}
+
+// endsSentence reports whether s ends with terminal punctuation, possibly followed by closing quotes or brackets
+func endsSentence(s string) bool {
+	s = strings.TrimRight(s, "”’\"')]")
+	return strings.HasSuffix(s, ".") || strings.HasSuffix(s, "!") || strings.HasSuffix(s, "?") || strings.HasSuffix(s, "…")
+}
+
+// quoteState tracks the quotations opened in a text. Single closing quotes between two letters are apostrophes,
+// the other ones are treated as apostrophes unless a single quotation is open.
+type quoteState struct {
+	double   int
+	single   int
+	straight bool
+}
+
+func (q *quoteState) scan(s string) {
+	runes := []rune(s)
+	for i, r := range runes {
+		switch r {
+		case '“':
+			q.double++
+		case '”':
+			if q.double > 0 {
+				q.double--
+			}
+		case '‘':
+			q.single++
+		case '’':
+			if i > 0 && i < len(runes)-1 && unicode.IsLetter(runes[i-1]) && unicode.IsLetter(runes[i+1]) {
+				continue
+			}
+			if q.single > 0 {
+				q.single--
+			}
+		case '"':
+			q.straight = !q.straight
+		}
+	}
+}
+
+func (q *quoteState) balanced() bool {
+	return q.double == 0 && q.single == 0 && !q.straight
+}
@@ -0,0 +1,133 @@
+package filters
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestExcerpt(t *testing.T) {
+	text := "Social media platforms are struggling. States have passed laws in recent weeks. Critics argue the measures are poorly drafted."
+
+	tests := map[string]struct {
+		text     string
+		opts     ExcerptOptions
+		expected string
+	}{
+		"no limits": {
+			text:     text,
+			expected: text,
+		},
+		"sentences": {
+			text:     text,
+			opts:     ExcerptOptions{MaxSentences: 2, Ellipsis: "…"},
+			expected: "Social media platforms are struggling. States have passed laws in recent weeks.",
+		},
+		"characters cut at sentence boundary": {
+			text:     text,
+			opts:     ExcerptOptions{MaxChars: 80},
+			expected: "Social media platforms are struggling. States have passed laws in recent weeks.",
+		},
+		"words cut at sentence boundary": {
+			text:     text,
+			opts:     ExcerptOptions{MaxWords: 12},
+			expected: "Social media platforms are struggling. States have passed laws in recent weeks.",
+		},
+		"first sentence too long": {
+			text:     text,
+			opts:     ExcerptOptions{MaxChars: 30, Ellipsis: "…"},
+			expected: "Social media platforms are…",
+		},
+		"trailing punctuation before ellipsis": {
+			text:     "Since the start of the year, Kraken agreed to end its staking business.",
+			opts:     ExcerptOptions{MaxWords: 6, Ellipsis: " ..."},
+			expected: "Since the start of the year ...",
+		},
+		"open quotation across sentences": {
+			text:     "He spoke. “Being able to prepare is a task. It is an engineering lift,” he said. Then more.",
+			opts:     ExcerptOptions{MaxSentences: 2},
+			expected: "He spoke.",
+		},
+		"apostrophe inside an open quotation": {
+			text:     "She spoke. ‘The bank’s model is broken. It cannot survive,’ she said. Next.",
+			opts:     ExcerptOptions{MaxSentences: 2},
+			expected: "She spoke.",
+		},
+		"quotation opening the text not closed within the limits": {
+			text:     "“Being able to prepare for this with confidence is a Herculean task,” she said.",
+			opts:     ExcerptOptions{MaxWords: 5, Ellipsis: "…"},
+			expected: "“Being able to prepare for…",
+		},
+		"quotation opening the text not closed within the sentences": {
+			text:     "‘The bank’s model is broken. It cannot survive,’ she said. Next.",
+			opts:     ExcerptOptions{MaxSentences: 1},
+			expected: "‘The bank’s model is broken.",
+		},
+		"apostrophe inside a closed quotation": {
+			text:     "‘The bank’s model is broken,’ she said. Next.",
+			opts:     ExcerptOptions{MaxSentences: 1},
+			expected: "‘The bank’s model is broken,’ she said.",
+		},
+		"open quotation without word limits": {
+			text:     "He said “this is the first. And the second” and more. Next.",
+			opts:     ExcerptOptions{MaxSentences: 1, Ellipsis: "…"},
+			expected: "He said…",
+		},
+		"open quotation inside first sentence": {
+			text:     "The person said “being able to prepare for this with confidence is a Herculean task” today.",
+			opts:     ExcerptOptions{MaxWords: 8},
+			expected: "The person said",
+		},
+		"apostrophes": {
+			text:     "US crypto exchanges’ offshore rivals have been able to launch products. The world’s largest exchange grew.",
+			opts:     ExcerptOptions{MaxSentences: 1},
+			expected: "US crypto exchanges’ offshore rivals have been able to launch products.",
+		},
+		"byline": {
+			text:     "By Hannah Murphy and Cristina Criddle in San Francisco | Social media platforms are struggling. More.",
+			opts:     ExcerptOptions{MaxSentences: 1},
+			expected: "Social media platforms are struggling.",
+		},
+		"caption with credit": {
+			text:     "Donald Trump at a rally in Iowa © AFP/Getty Images Trump remains the frontrunner. More.",
+			opts:     ExcerptOptions{MaxSentences: 1},
+			expected: "Trump remains the frontrunner.",
+		},
+		"caption with credit only": {
+			text:     "Donald Trump at a rally in Iowa © Reuters",
+			opts:     ExcerptOptions{MaxSentences: 1},
+			expected: "",
+		},
+		"copyright sign in running text": {
+			text:     "Shares in the company behind the © symbol font rose. More.",
+			opts:     ExcerptOptions{MaxSentences: 1},
+			expected: "Shares in the company behind the © symbol font rose.",
+		},
+		"labelled caption": {
+			text:     "Photo: Reuters. Trump remains the frontrunner. More.",
+			opts:     ExcerptOptions{MaxSentences: 1},
+			expected: "Trump remains the frontrunner.",
+		},
+		"not a byline": {
+			text:     "By contrast US crypto exchanges’ offshore rivals grew. More.",
+			opts:     ExcerptOptions{MaxSentences: 1},
+			expected: "By contrast US crypto exchanges’ offshore rivals grew.",
+		},
+		"skipping disabled": {
+			text:     "Photo: Reuters. Trump remains the frontrunner.",
+			opts:     ExcerptOptions{MaxSentences: 1, SkipPatterns: []*regexp.Regexp{}},
+			expected: "Photo: Reuters.",
+		},
+		"nothing fits": {
+			text:     "Unbelievably long words",
+			opts:     ExcerptOptions{MaxChars: 5, Ellipsis: "…"},
+			expected: "",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			equal(t, test.expected, Excerpt(test.text, test.opts), "")
+		})
+	}
+}
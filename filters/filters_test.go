@@ -2,6 +2,7 @@ package filters
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 )
 
@@ -99,6 +100,21 @@ func TestTagsRemover2(t *testing.T) {
 	equal(t, expected, result, "")
 }
 
+func TestApplyConcurrent(t *testing.T) {
+	input := "<body><p>this is a <b>simple</b>&nbsp;test</p><pull-quote>quote</pull-quote></body>"
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := Apply(input, DefaultContentFilters()...); got != "this is a simple test" {
+				t.Errorf("unexpected concurrent filter result: '%s'", got)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
 func equal(t *testing.T, expected, actual string, msg string) {
 	t.Helper()
 	if expected != actual {
@@ -0,0 +1,33 @@
+package filters
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var tagRegex = regexp.MustCompile(`<[^<>]*>`)
+
+var consecutiveSpaceRegex = regexp.MustCompile(`\s\s`)
+
+// FuzzApply checks that the default content filters never panic and always return trimmed text without tags
+func FuzzApply(f *testing.F) {
+	f.Add(`<body><p>Lorem <em>ipsum</em>&nbsp;dolor</p><pull-quote><pull-quote-text>quote</pull-quote-text></pull-quote></body>`)
+	f.Add(`this is a test<table style="width:100%"><tr><td>Jill</td></tr></table> followed by another test`)
+	f.Add("test &#8209;&pound;&amp;&nbsp;&gt;& \t\n lots  of\r\nspace")
+	f.Add(`&lt;b&gt;escaped tag&lt;/b&gt;`)
+
+	f.Fuzz(func(t *testing.T, text string) {
+		got := Apply(text, DefaultContentFilters()...)
+
+		if tag := tagRegex.FindString(got); tag != "" {
+			t.Fatalf("tag %q survived in %q", tag, got)
+		}
+		if got != strings.TrimSpace(got) {
+			t.Fatalf("output is not trimmed: %q", got)
+		}
+		if consecutiveSpaceRegex.MatchString(got) {
+			t.Fatalf("output has consecutive whitespace: %q", got)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package filters
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CountWords returns the number of words in a plain text, as produced by applying DefaultContentFilters.
+// Words are the runs of characters separated by whitespace.
+func CountWords(text string) int {
+	return len(strings.Fields(text))
+}
+
+// Sentences splits a plain text into sentences. A sentence ends with ".", "!" or "?", optionally followed by closing
+// quotes or brackets, when the next word starts with an upper case letter, a digit or an opening quote.
+// Full stops of abbreviations do not end a sentence, see isAbbreviation for the rules.
+func Sentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i := 0; i < len(text); {
+		r, width := utf8.DecodeRuneInString(text[i:])
+		i += width
+		if !isSentenceTerminator(r) {
+			continue
+		}
+		terminator := i - width
+		end := i
+		for end < len(text) {
+			r, width := utf8.DecodeRuneInString(text[end:])
+			if !isClosingPunctuation(r) && !isSentenceTerminator(r) {
+				break
+			}
+			end += width
+		}
+		next := end
+		for next < len(text) {
+			r, width := utf8.DecodeRuneInString(text[next:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			next += width
+		}
+		if next == end || next == len(text) {
+			i = end
+			continue
+		}
+		nextRune, _ := utf8.DecodeRuneInString(text[next:])
+		if !startsSentence(nextRune) || (r == '.' && end == i && isAbbreviation(text[:terminator], nextRune)) {
+			i = end
+			continue
+		}
+		sentences = append(sentences, strings.TrimSpace(text[start:end]))
+		start = next
+		i = next
+	}
+	if rest := strings.TrimSpace(text[start:]); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
+
+// titles are abbreviations written before a name, they never end a sentence
+var titles = map[string]bool{
+	"Mr": true, "Mrs": true, "Ms": true, "Dr": true, "Prof": true, "St": true, "Sen": true, "Rep": true, "Gov": true,
+	"Gen": true, "Col": true, "Capt": true, "Lt": true, "Sgt": true, "Rev": true, "Hon": true, "Mt": true,
+}
+
+// latinAbbreviations never end a sentence
+var latinAbbreviations = map[string]bool{
+	"e.g": true, "i.e": true, "cf": true, "viz": true, "vs": true, "approx": true,
+}
+
+// numberAbbreviations do not end a sentence when followed by a number, e.g. "Jan. 5" or "No. 10"
+var numberAbbreviations = map[string]bool{
+	"Jan": true, "Feb": true, "Mar": true, "Apr": true, "Jun": true, "Jul": true, "Aug": true, "Sep": true,
+	"Sept": true, "Oct": true, "Nov": true, "Dec": true, "No": true, "Nos": true, "Vol": true, "p": true, "pp": true,
+}
+
+// isAbbreviation reports whether the full stop at the end of before belongs to an abbreviation rather than ending
+// a sentence. Besides titles and latin abbreviations, dotted initialisms (U.S., a.m.) and single letter initials
+// (J. K. Rowling) are abbreviations. Other words ending in a full stop, like "US." or "Inc.", end the sentence when
+// the next word starts with an upper case letter.
+func isAbbreviation(before string, next rune) bool {
+	word := before[strings.LastIndexFunc(before, unicode.IsSpace)+1:]
+	word = strings.TrimLeft(word, "(“‘\"'")
+	switch {
+	case word == "":
+		return false
+	case titles[word], latinAbbreviations[strings.ToLower(word)]:
+		return true
+	case numberAbbreviations[word]:
+		return unicode.IsDigit(next)
+	case isInitialism(word):
+		return true
+	}
+	return false
+}
+
+// isInitialism matches single letters separated by full stops, e.g. "U.S" or "a.m", and single upper case letters
+func isInitialism(word string) bool {
+	letters := strings.Split(word, ".")
+	if len(letters) == 1 {
+		r, width := utf8.DecodeRuneInString(word)
+		return width == len(word) && unicode.IsUpper(r)
+	}
+	for _, l := range letters {
+		r, width := utf8.DecodeRuneInString(l)
+		if width != len(l) || !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isSentenceTerminator(r rune) bool {
+	return r == '.' || r == '!' || r == '?'
+}
+
+func isClosingPunctuation(r rune) bool {
+	return r == '"' || r == '\'' || r == '”' || r == '’' || r == ')' || r == ']'
+}
+
+func startsSentence(r rune) bool {
+	return unicode.IsUpper(r) || unicode.IsDigit(r) || r == '"' || r == '“' || r == '‘' || r == '\'' || r == '(' || r == '£' || r == '$' || r == '€'
+}
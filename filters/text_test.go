@@ -0,0 +1,58 @@
+package filters
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCountWords(t *testing.T) {
+	tests := map[string]int{
+		"":                                    0,
+		"one":                                 1,
+		"Nam scelerisque luctus — tristique.": 5,
+		" lots  of\tspace\nbut\nno room ":     6,
+	}
+	for text, expected := range tests {
+		if got := CountWords(text); got != expected {
+			t.Errorf("expected %d words in %q, got %d", expected, text, got)
+		}
+	}
+}
+
+func TestSentences(t *testing.T) {
+	tests := map[string][]string{
+		"":             nil,
+		"No full stop": {"No full stop"},
+		"First one. Second one! Third one? Fourth":                       {"First one.", "Second one!", "Third one?", "Fourth"},
+		"“Being able to prepare,” he said. “It was parents.” Then more.": {"“Being able to prepare,” he said.", "“It was parents.”", "Then more."},
+		"Prices rose 2.5 per cent. 2024 will be different.":              {"Prices rose 2.5 per cent.", "2024 will be different."},
+		"See this... and that. Done":                                     {"See this... and that.", "Done"},
+	}
+	for text, expected := range tests {
+		if got := Sentences(text); !reflect.DeepEqual(expected, got) {
+			t.Errorf("expected sentences %q in %q, got %q", expected, text, got)
+		}
+	}
+}
+
+func TestSentencesAbbreviations(t *testing.T) {
+	tests := map[string][]string{
+		"Mr Smith and Mrs. Jones met Dr. Brown. They agreed.":                        {"Mr Smith and Mrs. Jones met Dr. Brown.", "They agreed."},
+		"Prof. Sen. Warren spoke. Gov. Newsom did not.":                              {"Prof. Sen. Warren spoke.", "Gov. Newsom did not."},
+		"Exchanges are losing ground in the US. Binance grew.":                       {"Exchanges are losing ground in the US.", "Binance grew."},
+		"The U.S. Treasury and the U.K. agreed. Talks resume.":                       {"The U.S. Treasury and the U.K. agreed.", "Talks resume."},
+		"Apple Inc. said sales rose. Acme Inc. The deal failed.":                     {"Apple Inc. said sales rose.", "Acme Inc.", "The deal failed."},
+		"Some assets, e.g. Bitcoin, fell. Others, i.e. Ether, rose.":                 {"Some assets, e.g. Bitcoin, fell.", "Others, i.e. Ether, rose."},
+		"Tether rose to £82.5bn. Circle lost $1.5bn. Stablecoins fell 2.5 per cent.": {"Tether rose to £82.5bn.", "Circle lost $1.5bn.", "Stablecoins fell 2.5 per cent."},
+		"Trading opens at 9 a.m. New York time. It closes later.":                    {"Trading opens at 9 a.m. New York time.", "It closes later."},
+		"J. K. Rowling wrote it. It sold.":                                           {"J. K. Rowling wrote it.", "It sold."},
+		"The vote is on Jan. 5 in No. 10. Then recess. 2024 ends.":                   {"The vote is on Jan. 5 in No. 10.", "Then recess.", "2024 ends."},
+		"Talks ended in Jan. The deal was signed.":                                   {"Talks ended in Jan.", "The deal was signed."},
+		"“I need help,” he said. (See p. 3.) Next.":                                  {"“I need help,” he said.", "(See p. 3.)", "Next."},
+	}
+	for text, expected := range tests {
+		if got := Sentences(text); !reflect.DeepEqual(expected, got) {
+			t.Errorf("expected sentences %q in %q, got %q", expected, text, got)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package bodytransformer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/beevik/etree"
+)
+
+// maxFuzzBodySize bounds the fuzzed bodies to a few times the largest fixture. The transformation time grows with the
+// size of the body, and minimising large inputs stalled the fuzzer for minutes.
+const maxFuzzBodySize = 32 << 10
+
+// FuzzTransformBody checks the invariants of the transformation on arbitrary bodies, seeded with the golden fixtures
+func FuzzTransformBody(f *testing.F) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*", "content.html"))
+	if err != nil {
+		f.Fatalf("failed to list fixtures: %s", err.Error())
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatalf("failed to read fixture %s: %s", file, err.Error())
+		}
+		f.Add(string(data))
+	}
+	f.Add(`<pull-quote><p>quote</p></pull-quote>`)
+	f.Add(`<scrollable-block><scrollable-text><p>text</p></scrollable-text></scrollable-block>`)
+	f.Add(`<body><p title="&quot;quoted&quot;">a &lt;b&gt; &amp; c</p></body>`)
+	f.Add(`<body><p>]]&gt;</p></body>`)
+	f.Add(`<body><scrollable-block><scrollable-text><p>a</p><scrollable-text><p>b</p></scrollable-text></scrollable-text></scrollable-block></body>`)
+	f.Add(`<body><ft-content id="1" type="http://www.ft.com/ontology/content/Article">x</ft-content></body>`)
+
+	f.Fuzz(func(t *testing.T, body string) {
+		if len(body) > maxFuzzBodySize {
+			t.Skip()
+		}
+		got, err := TransformBody(body)
+		if err != nil {
+			return
+		}
+
+		doc := etree.NewDocument()
+		if err := doc.ReadFromString(got); err != nil {
+			t.Fatalf("output is not valid XML: %v\noutput: %q", err, got)
+		}
+
+		for _, name := range defaultStrippedElements {
+			if el := doc.FindElement("//" + name); el != nil {
+				t.Fatalf("stripped element %s survived: %q", name, got)
+			}
+		}
+
+		// an ft-content without url is only allowed for an input element of the same type that had no id to build it from
+		input := etree.NewDocument()
+		if err := input.ReadFromString(body); err != nil {
+			t.Fatalf("input transformed without error is not valid XML: %v", err)
+		}
+		withoutID := map[string]int{}
+		for _, path := range []string{"//content[@type]", "//ft-content[@type]"} {
+			for _, el := range input.FindElements(path) {
+				if el.SelectAttr("id") == nil && el.SelectAttr("url") == nil {
+					withoutID[el.SelectAttrValue("type", "")]++
+				}
+			}
+		}
+		for _, el := range doc.FindElements("//ft-content[@type]") {
+			if el.SelectAttr("url") != nil {
+				continue
+			}
+			typ := el.SelectAttrValue("type", "")
+			if withoutID[typ] == 0 {
+				t.Fatalf("ft-content of type %q lost its id without getting an url: %q", typ, got)
+			}
+			withoutID[typ]--
+		}
+
+		again, err := TransformBody(got)
+		if err != nil {
+			t.Fatalf("failed to transform the output again: %v\noutput: %q", err, got)
+		}
+		if again != got {
+			t.Fatalf("transformation is not stable\nfirst:  %q\nsecond: %q", got, again)
+		}
+	})
+}
@@ -2,4 +2,7 @@ module github.com/Financial-Times/cm-body-transformer
 
 go 1.22
 
-require github.com/beevik/etree v1.1.0
+require (
+	github.com/beevik/etree v1.1.0
+	golang.org/x/text v0.14.0
+)
@@ -0,0 +1,271 @@
+package bodytransformer
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Financial-Times/cm-body-transformer/filters"
+)
+
+var update = flag.Bool("update", false, "regenerate the golden files in testdata instead of comparing against them")
+
+// fixtureProfiles are the alternative option sets a fixture can be checked against.
+// The output for a profile is compared to expected.<profile>.html when the fixture has that file.
+var fixtureProfiles = map[string][]Option{
+	"keep-recommended": {KeepElements("recommended")},
+	"outline":          {KeepElements("experimental"), HeadingAnchors()},
+	"sanitised":        {Sanitise(SanitiseOptions{})},
+	"sections":         {SemanticScrollableBlocks(ScrollableOptions{ImagePlaceholder: "figure"})},
+	"containers":       {KeepElements("experimental"), UnwrapContainers(DefaultContainerRules...)},
+	"images":           {Images(fixtureImages)},
+}
+
+// fixtureImages resolves some of the image sets of the fixtures, the others are removed
+var fixtureImages = MemoryImageResolver{
+	"df30f7e7-e04d-452e-99fd-8fb81edb6887": {
+		Variants: []ImageVariant{
+			{URL: "https://images.ft.com/df30f7e7-700.jpg", Width: 700, Height: 394},
+			{URL: "https://images.ft.com/df30f7e7-1400.jpg", Width: 1400, Height: 788},
+		},
+		Alt:     "Coinbase logo on a smartphone",
+		Caption: "Coinbase is among the exchanges setting up offshore venues",
+		Credit:  "© Reuters",
+	},
+	"e2b00d23-f801-4116-a229-6c0e2bc2ce97": {
+		Variants: []ImageVariant{{URL: "https://images.ft.com/e2b00d23.png"}},
+		Alt:      "Chart showing spot market share of crypto exchanges",
+		Credit:   "Source: Kaiko",
+	},
+}
+
+// goldenOutput renders one of the golden files of a fixture from its content.html
+type goldenOutput struct {
+	file     string
+	required bool
+	render   func(body string) (string, error)
+}
+
+func goldenOutputs() []goldenOutput {
+	outputs := []goldenOutput{
+		{
+			file:     "expected.html",
+			required: true,
+			render:   TransformBody,
+		},
+		{
+			file: "expected.txt",
+			render: func(body string) (string, error) {
+				transformed, err := TransformBody(body)
+				if err != nil {
+					return "", err
+				}
+				return filters.Apply(transformed, filters.DefaultContentFilters()...), nil
+			},
+		},
+		{
+			file: "report.json",
+			render: func(body string) (string, error) {
+				_, report, err := Transform(body)
+				if err != nil {
+					return "", err
+				}
+				data, err := json.MarshalIndent(report, "", "  ")
+				return string(data), err
+			},
+		},
+	}
+
+	profiles := make([]string, 0, len(fixtureProfiles))
+	for profile := range fixtureProfiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+	for _, profile := range profiles {
+		opts := fixtureProfiles[profile]
+		outputs = append(outputs, goldenOutput{
+			file: "expected." + profile + ".html",
+			render: func(body string) (string, error) {
+				transformed, _, err := Transform(body, opts...)
+				return transformed, err
+			},
+		})
+	}
+	return outputs
+}
+
+// fixtureDirs returns every testdata/<name> directory that has a content.html file
+func fixtureDirs(t *testing.T) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join("testdata", "*", "content.html"))
+	if err != nil {
+		t.Fatalf("failed to list fixtures: %s", err.Error())
+	}
+	if len(files) == 0 {
+		t.Fatal("no fixtures found in testdata")
+	}
+	dirs := make([]string, 0, len(files))
+	for _, f := range files {
+		dirs = append(dirs, filepath.Dir(f))
+	}
+	return dirs
+}
+
+// checkGolden compares got with the content of the golden file, or overwrites the golden file when -update is set.
+// Optional golden files are only checked, or updated, if they already exist.
+func checkGolden(t *testing.T, path string, got string, required bool) {
+	t.Helper()
+	if _, err := os.Stat(path); err != nil && !required {
+		return
+	}
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("failed to update golden file %s: %s", path, err.Error())
+		}
+		return
+	}
+	expected := readFile(t, path)
+	if expected != got {
+		t.Errorf("output differs from %s:\n%s", path, unifiedDiff(expected, got))
+	}
+}
+
+var goldenTokenRegex = regexp.MustCompile(`<[^>]+>|[^<]+`)
+
+type diffLine struct {
+	text string
+	path string
+}
+
+// diffLines splits a body into one line per tag and per sentence of text, each annotated with its element path
+func diffLines(s string) []diffLine {
+	var lines []diffLine
+	var stack []string
+	for _, token := range goldenTokenRegex.FindAllString(s, -1) {
+		path := "/" + strings.Join(stack, "/")
+		switch {
+		case strings.HasPrefix(token, "</"):
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			lines = append(lines, diffLine{text: token, path: path})
+		case strings.HasPrefix(token, "<"):
+			lines = append(lines, diffLine{text: token, path: path})
+			if !strings.HasSuffix(token, "/>") && !strings.HasPrefix(token, "<!") && !strings.HasPrefix(token, "<?") {
+				name := strings.FieldsFunc(token[1:], func(r rune) bool { return r == ' ' || r == '>' || r == '/' || r == '\n' })
+				if len(name) > 0 {
+					stack = append(stack, name[0])
+				}
+			}
+		default:
+			for _, sentence := range splitSentences(token) {
+				lines = append(lines, diffLine{text: sentence, path: path})
+			}
+		}
+	}
+	return lines
+}
+
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i := 0; i < len(text)-1; i++ {
+		if strings.ContainsRune(".!?\n", rune(text[i])) && text[i+1] == ' ' {
+			sentences = append(sentences, text[start:i+1])
+			start = i + 1
+		}
+	}
+	return append(sentences, text[start:])
+}
+
+// unifiedDiff returns a unified diff between the element-aware line splits of expected and got
+func unifiedDiff(expected, got string) string {
+	const context = 3
+	a, b := diffLines(expected), diffLines(got)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].text == b[j].text {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type edit struct {
+		op   byte
+		line diffLine
+		i, j int
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i].text == b[j].text:
+			edits = append(edits, edit{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', a[i], i, j})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j], i, j})
+			j++
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("--- expected\n+++ got\n")
+	for k := 0; k < len(edits); {
+		if edits[k].op == ' ' {
+			k++
+			continue
+		}
+		start := max(k-context, 0)
+		end := k
+		// extend the hunk while the changes are separated by less than two contexts of unchanged lines
+		for end < len(edits) {
+			if edits[end].op != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(edits) && edits[next].op == ' ' {
+				next++
+			}
+			if next == len(edits) || next-end > 2*context {
+				end = min(end+context, len(edits))
+				break
+			}
+			end = next
+		}
+
+		aCount, bCount := 0, 0
+		for _, e := range edits[start:end] {
+			if e.op != '+' {
+				aCount++
+			}
+			if e.op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@ %s\n", edits[start].i+1, aCount, edits[start].j+1, bCount, edits[k].line.path)
+		for _, e := range edits[start:end] {
+			fmt.Fprintf(&sb, "%c%s\n", e.op, e.line.text)
+		}
+		k = end
+	}
+	return sb.String()
+}
@@ -0,0 +1,115 @@
+package bodytransformer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// ErrImageNotFound is returned by an ImageResolver that does not know the requested image set
+var ErrImageNotFound = errors.New("image not found")
+
+// ImageVariant is a rendition of an image at a given size, Width and Height are in pixels and zero when unknown
+type ImageVariant struct {
+	URL    string
+	Width  int
+	Height int
+}
+
+// Image is the resolved content of an image set
+type Image struct {
+	// Variants are the renditions of the image, the first one is used as the src of the img element
+	Variants []ImageVariant
+	Alt      string
+	Caption  string
+	Credit   string
+}
+
+// ImageResolver resolves the uuid of an image set to its image. It returns an error wrapping ErrImageNotFound for
+// the image sets it does not know, any other error fails the transformation.
+type ImageResolver interface {
+	ResolveImage(ctx context.Context, uuid string) (Image, error)
+}
+
+// MemoryImageResolver is an ImageResolver holding the images by uuid, e.g. for tests
+type MemoryImageResolver map[string]Image
+
+// ResolveImage returns the image with the given uuid or ErrImageNotFound
+func (r MemoryImageResolver) ResolveImage(_ context.Context, uuid string) (Image, error) {
+	image, ok := r[uuid]
+	if !ok {
+		return Image{}, fmt.Errorf("%w: %s", ErrImageNotFound, uuid)
+	}
+	return image, nil
+}
+
+// Images expands the ImageSet references of the body into figure markup with the images returned by the resolver,
+// instead of removing them, and keeps the img elements of the body. The image sets the resolver does not know are
+// reported and removed as they are by default, the other resolver errors fail the transformation.
+// Use TransformContext to cancel the lookups.
+func Images(resolver ImageResolver) Option {
+	return func(cfg *config) {
+		cfg.images = resolver
+		KeepElements("img")(cfg)
+	}
+}
+
+// expandImages replaces every ImageSet reference the resolver knows with a figure
+func expandImages(ctx context.Context, doc *etree.Document, resolver ImageResolver, report *Report) error {
+	for _, el := range doc.FindElements("//ft-content[@type='http://www.ft.com/ontology/content/ImageSet']") {
+		uuid := uuidFromURL(el.SelectAttrValue("url", ""))
+		image, err := resolver.ResolveImage(ctx, uuid)
+		if err != nil && !errors.Is(err, ErrImageNotFound) {
+			return fmt.Errorf("failed to resolve image set %s: %w", uuid, err)
+		}
+		if err != nil || len(image.Variants) == 0 {
+			report.add(RuleSkipImage, el)
+			continue
+		}
+		report.add(RuleExpandImage, el)
+		parent := el.Parent()
+		parent.InsertChildAt(el.Index(), imageFigure(image))
+		parent.RemoveChild(el)
+	}
+	return nil
+}
+
+// imageFigure returns the figure element of the image: an img element with the srcset of the image variants and
+// a figcaption with the caption and the credit of the image
+func imageFigure(image Image) *etree.Element {
+	figure := etree.NewElement("figure")
+	img := figure.CreateElement("img")
+	first := image.Variants[0]
+	img.CreateAttr("src", first.URL)
+
+	var srcset []string
+	for _, v := range image.Variants {
+		if v.Width > 0 {
+			srcset = append(srcset, v.URL+" "+strconv.Itoa(v.Width)+"w")
+		}
+	}
+	if len(srcset) > 1 {
+		img.CreateAttr("srcset", strings.Join(srcset, ", "))
+	}
+	img.CreateAttr("alt", image.Alt)
+	if first.Width > 0 && first.Height > 0 {
+		img.CreateAttr("width", strconv.Itoa(first.Width))
+		img.CreateAttr("height", strconv.Itoa(first.Height))
+	}
+
+	if image.Caption != "" || image.Credit != "" {
+		caption := figure.CreateElement("figcaption")
+		caption.SetText(image.Caption)
+		if image.Credit != "" {
+			if image.Caption != "" {
+				caption.CreateText(" ")
+			}
+			caption.CreateElement("small").SetText(image.Credit)
+		}
+	}
+	return figure
+}
@@ -0,0 +1,111 @@
+package bodytransformer
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestImages(t *testing.T) {
+	resolver := MemoryImageResolver{
+		"1": {
+			Variants: []ImageVariant{{URL: "https://images.ft.com/1-small.jpg", Width: 320, Height: 180}, {URL: "https://images.ft.com/1-large.jpg", Width: 1280, Height: 720}},
+			Alt:      "Alt text",
+			Caption:  "Caption",
+			Credit:   "© FT",
+		},
+		"2": {Variants: []ImageVariant{{URL: "https://images.ft.com/2.jpg"}}},
+		"3": {},
+	}
+	imageSet := func(id string) string {
+		return `<content data-embedded="true" id="` + id + `" type="http://www.ft.com/ontology/content/ImageSet"/>`
+	}
+
+	tests := map[string]struct {
+		body     string
+		expected string
+		expanded int
+		removed  int
+	}{
+		"figure with srcset and caption": {
+			body: `<body>` + imageSet("1") + `<p>Text</p></body>`,
+			expected: `<body><figure><img src="https://images.ft.com/1-small.jpg" srcset="https://images.ft.com/1-small.jpg 320w, https://images.ft.com/1-large.jpg 1280w" ` +
+				`alt="Alt text" width="320" height="180"/><figcaption>Caption <small>© FT</small></figcaption></figure><p>Text</p></body>`,
+			expanded: 1,
+		},
+		"single variant without caption": {
+			body:     `<body>` + imageSet("2") + `</body>`,
+			expected: `<body><figure><img src="https://images.ft.com/2.jpg" alt=""/></figure></body>`,
+			expanded: 1,
+		},
+		"unresolved image sets are removed": {
+			body:     `<body>` + imageSet("3") + imageSet("4") + `<p>Text</p></body>`,
+			expected: `<body><p>Text</p></body>`,
+			removed:  2,
+		},
+		"inline images are kept": {
+			body:     `<body><p><img src="https://images.ft.com/inline.jpg" alt="inline"/></p></body>`,
+			expected: `<body><p><img src="https://images.ft.com/inline.jpg" alt="inline"/></p></body>`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, report, err := Transform(test.body, Images(resolver))
+			if err != nil {
+				t.Fatalf("unexpected transformation error: %s", err.Error())
+			}
+			if got != test.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s\n", test.expected, got)
+			}
+			if report.Count(RuleExpandImage) != test.expanded || report.Count(RuleRemoveResource) != test.removed ||
+				report.Count(RuleSkipImage) != test.removed {
+				t.Fatalf("expected %d expanded and %d skipped and removed images, got report:\n%s\n", test.expanded, test.removed, report)
+			}
+
+			again, _, err := Transform(got, Images(resolver))
+			if err != nil {
+				t.Fatalf("unexpected transformation error: %s", err.Error())
+			}
+			if again != got {
+				t.Fatalf("expected the output to be stable, got:\n%s\n", again)
+			}
+		})
+	}
+}
+
+// contextImageResolver fails with the error of its context, or with err when the context is not done
+type contextImageResolver struct {
+	err error
+}
+
+func (r contextImageResolver) ResolveImage(ctx context.Context, _ string) (Image, error) {
+	if err := ctx.Err(); err != nil {
+		return Image{}, err
+	}
+	return Image{}, r.err
+}
+
+func TestImagesResolverErrors(t *testing.T) {
+	body := `<body><content data-embedded="true" id="1" type="http://www.ft.com/ontology/content/ImageSet"/><p>Text</p></body>`
+	unavailable := errors.New("503 service unavailable")
+
+	_, _, err := Transform(body, Images(contextImageResolver{err: unavailable}))
+	if !errors.Is(err, unavailable) {
+		t.Fatalf("expected the resolver error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = TransformContext(ctx, body, Images(contextImageResolver{}))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancellation error, got %v", err)
+	}
+}
+
+func TestMemoryImageResolver(t *testing.T) {
+	_, err := MemoryImageResolver{}.ResolveImage(context.Background(), "missing")
+	if !errors.Is(err, ErrImageNotFound) {
+		t.Fatalf("expected ErrImageNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package bodytransformer
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// CanonicalHost is the scheme and host ft.com links are canonicalised to
+const CanonicalHost = "https://www.ft.com"
+
+// DefaultLinkHosts are the hosts of the links rewritten by RewriteLinks
+var DefaultLinkHosts = []string{"ft.com", "www.ft.com"}
+
+// TrackingParameters are the query parameters removed by the default link transforms,
+// a trailing * matches any parameter with the prefix
+var TrackingParameters = []string{"utm_*", "segmentId", "ftcamp"}
+
+// URLTransform rewrites a link URL in place
+type URLTransform func(u *url.URL)
+
+// DefaultLinkTransforms canonicalise the links to CanonicalHost and remove the TrackingParameters
+var DefaultLinkTransforms = []URLTransform{
+	CanonicaliseHost(CanonicalHost),
+	RemoveQueryParameters(TrackingParameters...),
+}
+
+// LinkOptions configures the RewriteLinks rule
+type LinkOptions struct {
+	// Hosts are the hosts of the links rewritten, DefaultLinkHosts when nil. Links to other hosts are left untouched.
+	Hosts []string
+	// Transforms are applied in order to the URL of every rewritten link, DefaultLinkTransforms when nil
+	Transforms []URLTransform
+	// Rel, when set, is added to the rel attribute of the rewritten anchors, e.g. nofollow
+	Rel string
+	// Target, when set, is the target attribute of the rewritten anchors, e.g. _blank
+	Target string
+}
+
+// RewriteLinks rewrites the href of the anchors and the url of the ft-content elements pointing to one of the hosts
+// with the pipeline of URL transforms, and adds the rel and target attributes to the rewritten anchors
+func RewriteLinks(opts LinkOptions) Option {
+	return func(cfg *config) {
+		if opts.Hosts == nil {
+			opts.Hosts = DefaultLinkHosts
+		}
+		if opts.Transforms == nil {
+			opts.Transforms = DefaultLinkTransforms
+		}
+		cfg.links = &opts
+	}
+}
+
+// CanonicaliseHost returns a transform replacing the scheme and host of the URL with the ones of base,
+// e.g. https://www.ft.com, when the URL is on the host of base or on the same host without www.
+// URLs on other hosts, like markets.ft.com, are left untouched.
+func CanonicaliseHost(base string) URLTransform {
+	canonical, err := url.Parse(base)
+	return func(u *url.URL) {
+		if err != nil {
+			return
+		}
+		host := u.Hostname()
+		if !strings.EqualFold(host, canonical.Hostname()) && !strings.EqualFold(host, strings.TrimPrefix(canonical.Hostname(), "www.")) {
+			return
+		}
+		u.Scheme = canonical.Scheme
+		u.Host = canonical.Host
+	}
+}
+
+// RemoveQueryParameters returns a transform removing the query parameters with the given names from the URL,
+// a trailing * matches any parameter with the prefix, e.g. utm_*. The order of the other parameters is kept.
+func RemoveQueryParameters(names ...string) URLTransform {
+	return func(u *url.URL) {
+		u.RawQuery = filterQuery(u.RawQuery, func(name string) bool {
+			return !matchesParameter(names, name)
+		})
+	}
+}
+
+// AddQueryParameters returns a transform setting the query parameters of the URL, e.g. the campaign parameters of a
+// partner. The parameters are appended in name order, replacing the ones of the URL with the same names.
+func AddQueryParameters(params map[string]string) URLTransform {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return func(u *url.URL) {
+		query := filterQuery(u.RawQuery, func(name string) bool {
+			_, ok := params[name]
+			return !ok
+		})
+		for _, name := range names {
+			if query != "" {
+				query += "&"
+			}
+			query += url.QueryEscape(name) + "=" + url.QueryEscape(params[name])
+		}
+		u.RawQuery = query
+	}
+}
+
+// filterQuery returns the parameters of the raw query whose names are kept by keep, in their original order and encoding
+func filterQuery(rawQuery string, keep func(name string) bool) string {
+	if rawQuery == "" {
+		return ""
+	}
+	var kept []string
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if keep(name) {
+			kept = append(kept, param)
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+func matchesParameter(names []string, name string) bool {
+	for _, n := range names {
+		if prefix, ok := strings.CutSuffix(n, "*"); (ok && strings.HasPrefix(name, prefix)) || n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// rewriteLinks applies the link options to the anchors and ft-content elements of the document
+func rewriteLinks(doc *etree.Document, opts LinkOptions, report *Report) {
+	for _, el := range doc.FindElements("//a[@href]") {
+		if !rewriteLinkAttribute(el, "href", opts) {
+			continue
+		}
+		report.add(RuleRewriteLink, el)
+		if opts.Rel != "" {
+			rel := strings.Fields(el.SelectAttrValue("rel", ""))
+			for _, value := range strings.Fields(opts.Rel) {
+				if !contains(rel, value) {
+					rel = append(rel, value)
+				}
+			}
+			el.CreateAttr("rel", strings.Join(rel, " "))
+		}
+		if opts.Target != "" {
+			el.CreateAttr("target", opts.Target)
+		}
+	}
+	for _, el := range doc.FindElements("//ft-content[@url]") {
+		if rewriteLinkAttribute(el, "url", opts) {
+			report.add(RuleRewriteLink, el)
+		}
+	}
+}
+
+// rewriteLinkAttribute applies the transforms to the URL in the attribute of el and reports whether the URL points
+// to one of the hosts of the options
+func rewriteLinkAttribute(el *etree.Element, key string, opts LinkOptions) bool {
+	u, err := url.Parse(strings.TrimSpace(el.SelectAttrValue(key, "")))
+	if err != nil || !containsHost(opts.Hosts, u.Hostname()) {
+		return false
+	}
+	for _, transform := range opts.Transforms {
+		transform(u)
+	}
+	el.CreateAttr(key, u.String())
+	return true
+}
+
+func containsHost(hosts []string, host string) bool {
+	for _, h := range hosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package bodytransformer
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestRewriteLinks(t *testing.T) {
+	tests := map[string]struct {
+		opts     LinkOptions
+		body     string
+		expected string
+		rewrites int
+	}{
+		"default transforms": {
+			body: `<body><p><a href="http://ft.com/content/1?utm_source=x&amp;page=2&amp;segmentId=abc&amp;ftcamp=y#top">one</a> ` +
+				`<a href="https://FT.com/world">two</a> <a href="https://example.com/?utm_source=x">other</a> ` +
+				`<a href="/content/3">relative</a></p></body>`,
+			expected: `<body><p><a href="https://www.ft.com/content/1?page=2#top">one</a> ` +
+				`<a href="https://www.ft.com/world">two</a> <a href="https://example.com/?utm_source=x">other</a> ` +
+				`<a href="/content/3">relative</a></p></body>`,
+			rewrites: 2,
+		},
+		"api urls of ft-content are kept": {
+			body:     `<body><p><content id="0c0f9670" type="http://www.ft.com/ontology/content/Article">story</content></p></body>`,
+			expected: `<body><p><ft-content type="http://www.ft.com/ontology/content/Article" url="http://api.ft.com/content/0c0f9670">story</ft-content></p></body>`,
+		},
+		"partner parameters, rel and target": {
+			opts: LinkOptions{
+				Transforms: append(append([]URLTransform(nil), DefaultLinkTransforms...),
+					AddQueryParameters(map[string]string{"partner": "acme news", "campaign": "syndication"})),
+				Rel:    "nofollow noopener",
+				Target: "_blank",
+			},
+			body: `<body><p><a href="https://www.ft.com/content/1?utm_medium=email&amp;partner=old&amp;page=2" rel="noopener">one</a> ` +
+				`<a href="https://example.com/">other</a></p></body>`,
+			expected: `<body><p><a href="https://www.ft.com/content/1?page=2&amp;campaign=syndication&amp;partner=acme+news" ` +
+				`rel="noopener nofollow" target="_blank">one</a> <a href="https://example.com/">other</a></p></body>`,
+			rewrites: 1,
+		},
+		"custom hosts": {
+			opts: LinkOptions{Hosts: []string{"markets.ft.com"}},
+			body: `<body><p><a href="https://markets.ft.com/data?s=FTSE&amp;utm_campaign=x">data</a> ` +
+				`<a href="https://ft.com/content/1?utm_campaign=x">one</a></p></body>`,
+			expected: `<body><p><a href="https://markets.ft.com/data?s=FTSE">data</a> ` +
+				`<a href="https://ft.com/content/1?utm_campaign=x">one</a></p></body>`,
+			rewrites: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, report, err := Transform(test.body, RewriteLinks(test.opts))
+			if err != nil {
+				t.Fatalf("unexpected transformation error: %s", err.Error())
+			}
+			if got != test.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s\n", test.expected, got)
+			}
+			if report.Count(RuleRewriteLink) != test.rewrites {
+				t.Fatalf("expected %d rewritten links, got report:\n%s\n", test.rewrites, report)
+			}
+		})
+	}
+}
+
+func TestRemoveQueryParameters(t *testing.T) {
+	tests := map[string]string{
+		"https://www.ft.com/":                              "https://www.ft.com/",
+		"https://www.ft.com/?utm_source=a&utm_term=b":      "https://www.ft.com/",
+		"https://www.ft.com/?b=2&utm_source=a&a=1":         "https://www.ft.com/?b=2&a=1",
+		"https://www.ft.com/?utm%5Fsource=a&x=%20&&ftcamp": "https://www.ft.com/?x=%20",
+		"https://www.ft.com/?segmentIds=1&segmentId=2":     "https://www.ft.com/?segmentIds=1",
+	}
+	transform := RemoveQueryParameters(TrackingParameters...)
+	for raw, expected := range tests {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatalf("unexpected parse error: %s", err.Error())
+		}
+		transform(u)
+		if got := u.String(); got != expected {
+			t.Errorf("%s: expected %s, got %s", raw, expected, got)
+		}
+	}
+}
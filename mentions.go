@@ -0,0 +1,76 @@
+package bodytransformer
+
+import (
+	"path"
+
+	"github.com/beevik/etree"
+)
+
+// Mention is an annotated concept or content reference found in a transformed body
+type Mention struct {
+	// Element is the tag name of the reference, ft-concept or ft-content
+	Element string
+	UUID    string
+	Type    string
+	// URL is the API url of the reference, empty when its type has no known API path
+	URL string
+	// Text is the anchor text of the reference
+	Text string
+	// Start and End are the offsets of Text in the plain text of the body, counted in runes (unicode code points)
+	Start int
+	End   int
+}
+
+// ExtractMentions transforms the body, customised by opts, and returns its plain text together with the ft-concept
+// and ft-content references found in it. The plain text is the same text filters.DefaultContentFilters produces
+// from the transformed body, so the offsets of the mentions can be used on the output of the filters too.
+func ExtractMentions(body string, opts ...Option) (string, []Mention, error) {
+	doc, err := parseBody(body)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := transformDocument(doc, newConfig(opts), &Report{}); err != nil {
+		return "", nil, err
+	}
+
+	var text plainText
+	var mentions []Mention
+	starts := map[*etree.Element]int{}
+	text.render(&doc.Element, func(el *etree.Element, start bool) {
+		if el.Tag != "ft-concept" && el.Tag != "ft-content" {
+			return
+		}
+		if start {
+			starts[el] = len(text.runes)
+			return
+		}
+		url := el.SelectAttrValue("url", "")
+		mention := Mention{
+			Element: el.Tag,
+			UUID:    uuidFromURL(url),
+			Type:    el.SelectAttrValue("type", ""),
+			URL:     url,
+			Start:   starts[el],
+			End:     max(text.offset(), starts[el]),
+		}
+		if _, ok := apiPaths[mention.Type]; !ok {
+			mention.URL = ""
+		}
+		mentions = append(mentions, mention)
+	})
+
+	plain := []rune(text.String())
+	for i := range mentions {
+		mentions[i].Start = text.trimmedOffset(mentions[i].Start)
+		mentions[i].End = text.trimmedOffset(mentions[i].End)
+		mentions[i].Text = string(plain[mentions[i].Start:mentions[i].End])
+	}
+	return string(plain), mentions, nil
+}
+
+func uuidFromURL(url string) string {
+	if url == "" {
+		return ""
+	}
+	return path.Base(url)
+}
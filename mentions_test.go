@@ -0,0 +1,79 @@
+package bodytransformer
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Financial-Times/cm-body-transformer/filters"
+)
+
+func TestExtractMentions(t *testing.T) {
+	body := `<body><p>  Shares in <concept id="b2d7e4a1" type="http://www.ft.com/ontology/company/PublicCompany"> Acme   Corp</concept> rose.</p>` +
+		`<pull-quote><pull-quote-text><p>Ignored <concept id="x" type="t">quote</concept></p></pull-quote-text></pull-quote>` +
+		`<p>Read <content id="3f1c" type="http://www.ft.com/ontology/content/Article">the <em>full</em> story</content>, ` +
+		`€5bn <concept id="c1" type="http://www.ft.com/ontology/person/Person"/></p></body>`
+
+	text, mentions, err := ExtractMentions(body)
+	if err != nil {
+		t.Fatalf("unexpected extraction error: %s", err.Error())
+	}
+
+	expectedText := "Shares in Acme Corp rose. Read the full story , €5bn"
+	if text != expectedText {
+		t.Fatalf("expected text %q, got %q", expectedText, text)
+	}
+	expected := []Mention{
+		{
+			Element: "ft-concept",
+			UUID:    "b2d7e4a1",
+			Type:    "http://www.ft.com/ontology/company/PublicCompany",
+			URL:     "http://api.ft.com/organisations/b2d7e4a1",
+			Text:    "Acme Corp",
+			Start:   10,
+			End:     19,
+		},
+		{
+			Element: "ft-content",
+			UUID:    "3f1c",
+			Type:    "http://www.ft.com/ontology/content/Article",
+			URL:     "http://api.ft.com/content/3f1c",
+			Text:    "the full story",
+			Start:   31,
+			End:     45,
+		},
+		{
+			Element: "ft-concept",
+			UUID:    "c1",
+			Type:    "http://www.ft.com/ontology/person/Person",
+			Start:   52,
+			End:     52,
+		},
+	}
+	if !reflect.DeepEqual(expected, mentions) {
+		t.Fatalf("expected:\n%+v\ngot:\n%+v\n", expected, mentions)
+	}
+}
+
+func TestExtractMentionsMatchesFilters(t *testing.T) {
+	for _, dir := range fixtureDirs(t) {
+		body := readFile(t, filepath.Join(dir, "content.html"))
+		transformed, err := TransformBody(body)
+		if err != nil {
+			t.Fatalf("unexpected transformation error: %s", err.Error())
+		}
+
+		text, mentions, err := ExtractMentions(body)
+		if err != nil {
+			t.Fatalf("unexpected extraction error: %s", err.Error())
+		}
+		if expected := filters.Apply(transformed, filters.DefaultContentFilters()...); text != expected {
+			t.Errorf("%s: plain text differs from the filtered text:\n%s\n%s", dir, expected, text)
+		}
+		for _, m := range mentions {
+			if got := string([]rune(text)[m.Start:m.End]); got != m.Text {
+				t.Errorf("%s: mention text %q does not match the text at its offsets %q", dir, m.Text, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package bodytransformer
+
+import "context"
+
+// Option customises the rules applied by Transform
+type Option func(*config)
+
+type config struct {
+	strippedElements  []string
+	headingAnchors    bool
+	typography        *TypographyOptions
+	sanitise          *SanitiseOptions
+	scrollable        *ScrollableOptions
+	containerRules    []compiledContainerRule
+	tweets            *TweetOptions
+	attributeRules    []AttributeRule
+	images            ImageResolver
+	simplifyTables    bool
+	convertBigNumbers bool
+	convertPullQuotes bool
+	timelines         *TimelineOptions
+	links             *LinkOptions
+	// related, when set, receives the related content links collected by ExtractRelated
+	related *[]RelatedItem
+	// ctx is passed to the lookups made by the rules
+	ctx context.Context
+	// err is the first invalid option, returned by the transformation
+	err error
+}
+
+// defaultStrippedElements are the tag names of the elements removed from the body together with their content
+var defaultStrippedElements = []string{
+	"pull-quote", "promo-box", "ft-related", "timeline", "ft-timeline", "table", "big-number", "img",
+	"experimental",
+	"recommended",
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{
+		ctx:              context.Background(),
+		strippedElements: append([]string(nil), defaultStrippedElements...),
+		attributeRules:   append([]AttributeRule(nil), defaultAttributeRules...),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// fail records the error of an invalid option, the transformation returns the first one
+func (cfg *config) fail(err error) {
+	if cfg.err == nil {
+		cfg.err = err
+	}
+}
+
+// KeepElements leaves the elements with the given tag names in the body instead of stripping them
+func KeepElements(names ...string) Option {
+	return func(cfg *config) {
+		kept := map[string]bool{}
+		for _, name := range names {
+			kept[name] = true
+		}
+		var stripped []string
+		for _, name := range cfg.strippedElements {
+			if !kept[name] {
+				stripped = append(stripped, name)
+			}
+		}
+		cfg.strippedElements = stripped
+	}
+}
+
+// StripElements removes the elements with the given tag names from the body in addition to the default ones
+func StripElements(names ...string) Option {
+	return func(cfg *config) {
+		for _, name := range names {
+			if !contains(cfg.strippedElements, name) {
+				cfg.strippedElements = append(cfg.strippedElements, name)
+			}
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package bodytransformer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/beevik/etree"
+)
+
+// Heading is a node of the heading outline of a body
+type Heading struct {
+	// Level is the heading level, 1 for h1 to 6 for h6
+	Level int
+	Text  string
+	// ID is the anchor of the heading, the one added to the transformed body by the HeadingAnchors option
+	ID       string
+	Children []*Heading
+}
+
+// HeadingAnchors adds stable id attributes to the heading elements of the transformed body, so that clients can link
+// to its sections. The ids are made from the heading text and de-duplicated with a numeric suffix.
+// Headings that already have an id keep it.
+func HeadingAnchors() Option {
+	return func(cfg *config) {
+		cfg.headingAnchors = true
+	}
+}
+
+// Outline transforms the body, customised by opts, and returns the tree of its headings.
+// A heading is nested under the closest preceding heading of a lower level.
+func Outline(body string, opts ...Option) ([]*Heading, error) {
+	doc, err := parseBody(body)
+	if err != nil {
+		return nil, err
+	}
+	if err := transformDocument(doc, newConfig(opts), &Report{}); err != nil {
+		return nil, err
+	}
+
+	var roots []*Heading
+	var stack []*Heading
+	ids := headingIDs(doc)
+	for _, el := range headingElements(&doc.Element) {
+		h := &Heading{Level: headingLevel(el), Text: elementText(el), ID: ids[el]}
+		for len(stack) > 0 && stack[len(stack)-1].Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, h)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, h)
+		}
+		stack = append(stack, h)
+	}
+	return roots, nil
+}
+
+// addHeadingAnchors sets the id attribute of every heading that does not have one
+func addHeadingAnchors(doc *etree.Document, report *Report) {
+	ids := headingIDs(doc)
+	for _, el := range headingElements(&doc.Element) {
+		if el.SelectAttr("id") == nil {
+			el.CreateAttr("id", ids[el])
+			report.add(RuleHeadingAnchor, el)
+		}
+	}
+}
+
+// headingIDs returns the anchor of every heading in doc. Existing ids are kept, the others are slugs of the heading
+// text, suffixed with -2, -3... when the same slug was used by a previous heading.
+func headingIDs(doc *etree.Document) map[*etree.Element]string {
+	headings := headingElements(&doc.Element)
+	used := map[string]bool{}
+	for _, el := range headings {
+		if id := el.SelectAttrValue("id", ""); id != "" {
+			used[id] = true
+		}
+	}
+
+	ids := map[*etree.Element]string{}
+	for _, el := range headings {
+		if id := el.SelectAttrValue("id", ""); id != "" {
+			ids[el] = id
+			continue
+		}
+		slug := slugify(elementText(el))
+		id := slug
+		for i := 2; used[id]; i++ {
+			id = fmt.Sprintf("%s-%d", slug, i)
+		}
+		used[id] = true
+		ids[el] = id
+	}
+	return ids
+}
+
+// headingElements returns the h1-h6 descendants of el in document order
+func headingElements(el *etree.Element) []*etree.Element {
+	var headings []*etree.Element
+	for _, child := range el.ChildElements() {
+		if headingLevel(child) > 0 {
+			headings = append(headings, child)
+		}
+		headings = append(headings, headingElements(child)...)
+	}
+	return headings
+}
+
+func headingLevel(el *etree.Element) int {
+	if len(el.Tag) == 2 && el.Tag[0] == 'h' && el.Tag[1] >= '1' && el.Tag[1] <= '6' {
+		return int(el.Tag[1] - '0')
+	}
+	return 0
+}
+
+// slugify lower cases the text and joins its runs of letters and digits with "-"
+func slugify(text string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			sb.WriteRune(r)
+			dash = false
+			continue
+		}
+		if r != '’' && r != '\'' {
+			dash = true
+		}
+	}
+	if sb.Len() == 0 {
+		return "section"
+	}
+	return sb.String()
+}
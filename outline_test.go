@@ -0,0 +1,64 @@
+package bodytransformer
+
+import (
+	"reflect"
+	"testing"
+)
+
+const outlineBody = `<body><h1>Crypto’s offshore push</h1><p>intro</p>` +
+	`<h2>The US crackdown</h2><p>text</p><h3>SEC &amp; CFTC</h3><h3>Staking</h3>` +
+	`<h2 id="offshore">Going offshore</h2><h4>Bermuda</h4><h2>The US crackdown</h2><h2/>` +
+	`<experimental><h3>Digital assets dashboard</h3></experimental></body>`
+
+func TestOutline(t *testing.T) {
+	got, err := Outline(outlineBody)
+	if err != nil {
+		t.Fatalf("unexpected outline error: %s", err.Error())
+	}
+	expected := []*Heading{
+		{Level: 1, Text: "Crypto’s offshore push", ID: "cryptos-offshore-push", Children: []*Heading{
+			{Level: 2, Text: "The US crackdown", ID: "the-us-crackdown", Children: []*Heading{
+				{Level: 3, Text: "SEC & CFTC", ID: "sec-cftc"},
+				{Level: 3, Text: "Staking", ID: "staking"},
+			}},
+			{Level: 2, Text: "Going offshore", ID: "offshore", Children: []*Heading{
+				{Level: 4, Text: "Bermuda", ID: "bermuda"},
+			}},
+			{Level: 2, Text: "The US crackdown", ID: "the-us-crackdown-2"},
+			{Level: 2, Text: "", ID: "section"},
+		}},
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("unexpected outline:\n%s", outlineString(got, ""))
+	}
+}
+
+func TestHeadingAnchors(t *testing.T) {
+	body := `<body><h2>Results</h2><p>text</p><h3 id="q1">Results</h3><h3>Results</h3></body>`
+	expected := `<body><h2 id="results">Results</h2><p>text</p><h3 id="q1">Results</h3><h3 id="results-2">Results</h3></body>`
+
+	got, report, err := Transform(body, HeadingAnchors())
+	if err != nil {
+		t.Fatalf("unexpected transformation error: %s", err.Error())
+	}
+	if got != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s\n", expected, got)
+	}
+	if report.Count(RuleHeadingAnchor) != 2 {
+		t.Fatalf("expected 2 heading anchors in the report:\n%s", report)
+	}
+
+	again, _, err := Transform(got, HeadingAnchors())
+	if err != nil || again != got {
+		t.Fatalf("expected anchors to be stable, got:\n%s", again)
+	}
+}
+
+func outlineString(headings []*Heading, indent string) string {
+	s := ""
+	for _, h := range headings {
+		s += indent + "h" + string(rune('0'+h.Level)) + " " + h.Text + " #" + h.ID + "\n"
+		s += outlineString(h.Children, indent+"  ")
+	}
+	return s
+}
@@ -0,0 +1,431 @@
+package bodytransformer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	parityFixturesDir        = "testdata/parity"
+	documentStoreFixtureFile = "document-store.json"
+	publicContentFixtureFile = "public-content.json"
+
+	documentStorePath = "/__document-store-api/content/"
+	publicContentPath = "/content/"
+)
+
+// knownDifference is a category of differences between the library output and the public content API output
+// that is accepted by the parity tests. normalise rewrites a body so that the difference disappears.
+type knownDifference struct {
+	name      string
+	normalise func(string) string
+}
+
+var selfClosingFTTagRegex = regexp.MustCompile(`<(ft-[a-z-]+)((?:\s+[^\s=>]+="[^"]*")*)\s*/>`)
+
+// knownDifferences are the allowlisted differences, see TestBodyTransformation for details
+var knownDifferences = []knownDifference{
+	{
+		// self closed ft-concept/ft-content tags are written with start and end tags by public content API
+		name: "self-closing-tags",
+		normalise: func(body string) string {
+			return selfClosingFTTagRegex.ReplaceAllString(body, "<$1$2></$1>")
+		},
+	},
+	{
+		// public content API keeps some html escape sequences that the library un-escapes
+		name: "escaping",
+		normalise: func(body string) string {
+			return html.UnescapeString(body)
+		},
+	},
+}
+
+// parityClient accesses document store and public content API, or the replay server serving the recorded responses
+type parityClient struct {
+	httpClient        *http.Client
+	documentStoreURL  string
+	publicContentURL  string
+	apiKey            string
+	basicAuthUser     string
+	basicAuthPassword string
+}
+
+// checkParity transforms the document store body and compares it with the public content API body.
+// The bodies are at parity if Diff finds no semantic differences between them and the raw differences are all
+// explained by the allowlisted known differences, which are logged by name.
+func checkParity(t *testing.T, uuid string, docStoreBody string, publicAPIBody string) {
+	t.Helper()
+
+	transformedBody, err := TransformBody(docStoreBody)
+	if err != nil {
+		t.Fatalf("failed to transform content body: %v", err)
+	}
+
+	// Remove empty new lines for easier comparison later
+	publicAPIBody = removeEmptyLines(publicAPIBody)
+
+	if diffs := Diff(publicAPIBody, transformedBody, DiffOptions{}); len(diffs) > 0 {
+		var sb strings.Builder
+		for _, d := range diffs {
+			sb.WriteString(d.String())
+			sb.WriteString("\n")
+		}
+		t.Errorf("for %s transformed body is different than the content body returned by public content API:\n%s", uuid, sb.String())
+		return
+	}
+
+	allowed, explained := explainDifferences(publicAPIBody, transformedBody)
+	switch {
+	case !explained:
+		t.Errorf("for %s transformed body differs from public content API by differences not in knownDifferences:\n%s", uuid, unifiedDiff(publicAPIBody, transformedBody))
+	case len(allowed) > 0:
+		t.Logf("for %s transformed body differs from public content API only by known differences: %s", uuid, strings.Join(allowed, ", "))
+	}
+}
+
+// explainDifferences applies the known differences to both bodies and returns the names of the ones that changed
+// them, and whether the bodies are equal once normalised
+func explainDifferences(publicAPIBody, transformedBody string) ([]string, bool) {
+	var allowed []string
+	for _, diff := range knownDifferences {
+		if publicAPIBody == transformedBody {
+			break
+		}
+		normalisedPublic, normalisedTransformed := diff.normalise(publicAPIBody), diff.normalise(transformedBody)
+		if normalisedPublic != publicAPIBody || normalisedTransformed != transformedBody {
+			allowed = append(allowed, diff.name)
+		}
+		publicAPIBody, transformedBody = normalisedPublic, normalisedTransformed
+	}
+	return allowed, publicAPIBody == transformedBody
+}
+
+func runParity(t *testing.T, client parityClient, uuids []string) {
+	t.Helper()
+	for _, uuid := range uuids {
+		uuid := uuid
+		t.Run(uuid, func(t *testing.T) {
+			publicAPIBody := getPublicContentBody(t, uuid, client)
+			docStoreBody := getDocumentStoreBody(t, uuid, client)
+			checkParity(t, uuid, docStoreBody, publicAPIBody)
+		})
+	}
+}
+
+// TestBodyTransformationReplay runs the parity check offline against the responses recorded in testdata/parity by
+// TestBodyTransformation with -record. It is skipped when no responses were recorded.
+func TestBodyTransformationReplay(t *testing.T) {
+	dirs, err := os.ReadDir(parityFixturesDir)
+	if os.IsNotExist(err) {
+		t.Skip("no recorded parity fixtures, record them with the manualintegration tests and -record")
+	}
+	if err != nil {
+		t.Fatalf("failed to list parity fixtures: %v", err)
+	}
+	var uuids []string
+	for _, d := range dirs {
+		if d.IsDir() {
+			uuids = append(uuids, d.Name())
+		}
+	}
+	if len(uuids) == 0 {
+		t.Skip("no recorded parity fixtures")
+	}
+	replayParity(t, parityFixturesDir, uuids)
+}
+
+// TestBodyTransformationReplayGolden runs the parity check through the replay server on recordings built from the
+// golden fixtures named after their content uuid: content.html is the document store body and expected.html the
+// public content API body, written with start and end ft tags and escaped punctuation the way public content API
+// writes them
+func TestBodyTransformationReplayGolden(t *testing.T) {
+	dir := t.TempDir()
+	var uuids []string
+	for _, fixture := range fixtureDirs(t) {
+		uuid := filepath.Base(fixture)
+		if !fullUUIDRegex.MatchString(uuid) {
+			continue
+		}
+		public := selfClosingFTTagRegex.ReplaceAllString(readFile(t, filepath.Join(fixture, "expected.html")), "<$1$2></$1>")
+		public = publicAPIEscaper.Replace(public)
+		writeRecording(t, filepath.Join(dir, uuid, documentStoreFixtureFile), map[string]string{"body": readFile(t, filepath.Join(fixture, "content.html"))})
+		writeRecording(t, filepath.Join(dir, uuid, publicContentFixtureFile), map[string]string{"bodyXML": public})
+		uuids = append(uuids, uuid)
+	}
+	if len(uuids) == 0 {
+		t.Fatal("no golden fixtures named after a content uuid")
+	}
+	replayParity(t, dir, uuids)
+}
+
+var fullUUIDRegex = regexp.MustCompile(`^` + uuidRegex.String() + `$`)
+
+// publicAPIEscaper escapes the punctuation public content API writes as character references
+var publicAPIEscaper = strings.NewReplacer(
+	"\u2013", "&#8211;", "\u2014", "&#8212;", "\u2018", "&#8216;", "\u2019", "&#8217;", "\u201c", "&#8220;", "\u201d", "&#8221;",
+)
+
+// writeRecording writes a recorded JSON response
+func writeRecording(t *testing.T, file string, content map[string]string) {
+	t.Helper()
+	data, err := json.Marshal(content)
+	if err != nil {
+		t.Fatalf("failed to encode the recording: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatalf("failed to create the recording directory: %v", err)
+	}
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		t.Fatalf("failed to write the recording: %v", err)
+	}
+}
+
+// replayParity runs the parity check on the recordings of dir, served by the replay server
+func replayParity(t *testing.T, dir string, uuids []string) {
+	t.Helper()
+	server := httptest.NewServer(replayHandler(dir))
+	defer server.Close()
+
+	client := parityClient{
+		httpClient:       server.Client(),
+		documentStoreURL: server.URL + documentStorePath,
+		publicContentURL: server.URL + publicContentPath,
+	}
+	runParity(t, client, uuids)
+}
+
+// TestReplayHandler checks that the replay server serves synthetic responses to the parity client, it does not
+// check parity
+func TestReplayHandler(t *testing.T) {
+	dir := t.TempDir()
+	uuid := "synthetic"
+	if err := os.MkdirAll(filepath.Join(dir, uuid), 0o755); err != nil {
+		t.Fatalf("failed to create the synthetic fixture: %v", err)
+	}
+	for file, body := range map[string]string{
+		documentStoreFixtureFile: `{"bodyXML": "<body><p>document store</p></body>"}`,
+		publicContentFixtureFile: `{"bodyXML": "<body><p>public content</p></body>"}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, uuid, file), []byte(body), 0o644); err != nil {
+			t.Fatalf("failed to write the synthetic fixture: %v", err)
+		}
+	}
+
+	server := httptest.NewServer(replayHandler(dir))
+	defer server.Close()
+	client := parityClient{
+		httpClient:       server.Client(),
+		documentStoreURL: server.URL + documentStorePath,
+		publicContentURL: server.URL + publicContentPath,
+	}
+	if got := getDocumentStoreBody(t, uuid, client); got != "<body><p>document store</p></body>" {
+		t.Errorf("unexpected document store body %q", got)
+	}
+	if got := getPublicContentBody(t, uuid, client); got != "<body><p>public content</p></body>" {
+		t.Errorf("unexpected public content body %q", got)
+	}
+}
+
+// TestKnownDifferences checks that each known difference explains the difference it is named for, on markup taken
+// from the fixtures: the self-closed reference is the embedded video of 10979399, typed as content since videos are
+// removed, and the text is from 1bd99ff1
+func TestKnownDifferences(t *testing.T) {
+	tests := map[string]struct {
+		docStore string
+		public   string
+	}{
+		"self-closing-tags": {
+			docStore: `<body><p>Former chief of staff at the Commodity Futures Trading Commission.</p>` +
+				`<content data-embedded="true" id="db61d9b1-5244-4ba0-accc-7a85378313c0" type="http://www.ft.com/ontology/content/Content"/></body>`,
+			public: `<body><p>Former chief of staff at the Commodity Futures Trading Commission.</p>` +
+				`<ft-content data-embedded="true" type="http://www.ft.com/ontology/content/Content" url="http://api.ft.com/content/db61d9b1-5244-4ba0-accc-7a85378313c0"></ft-content></body>`,
+		},
+		"escaping": {
+			docStore: `<body><p>But critics — including the platforms themselves, as well as some children’s advocacy groups — argue the measures are poorly drafted.</p></body>`,
+			public:   `<body><p>But critics &#8212; including the platforms themselves, as well as some children&#8217;s advocacy groups &#8212; argue the measures are poorly drafted.</p></body>`,
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			transformed, err := TransformBody(test.docStore)
+			if err != nil {
+				t.Fatalf("failed to transform content body: %v", err)
+			}
+			if transformed == test.public {
+				t.Fatalf("expected the bodies to differ, got:\n%s\n", transformed)
+			}
+			if diffs := Diff(test.public, transformed, DiffOptions{}); len(diffs) > 0 {
+				t.Fatalf("expected no semantic differences, got %v", diffs)
+			}
+			allowed, explained := explainDifferences(test.public, transformed)
+			if !explained || len(allowed) != 1 || allowed[0] != name {
+				t.Fatalf("expected the difference to be explained by %s only, got %v:\n%s", name, allowed,
+					unifiedDiff(test.public, transformed))
+			}
+		})
+	}
+}
+
+// replayHandler serves the document store and public content API responses recorded in dir
+func replayHandler(dir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var file string
+		switch {
+		case strings.HasPrefix(r.URL.Path, documentStorePath):
+			file = documentStoreFixtureFile
+		case strings.HasPrefix(r.URL.Path, publicContentPath):
+			file = publicContentFixtureFile
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join(dir, path.Base(r.URL.Path), file))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}
+}
+
+// recordingTransport saves the document store and public content API responses as parity fixtures
+type recordingTransport struct {
+	next http.RoundTripper
+	dir  string
+}
+
+func (rt recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	var file string
+	switch {
+	case strings.HasPrefix(req.URL.Path, documentStorePath):
+		file = documentStoreFixtureFile
+	case strings.HasPrefix(req.URL.Path, publicContentPath):
+		file = publicContentFixtureFile
+	default:
+		return resp, nil
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	dir := filepath.Join(rt.dir, path.Base(req.URL.Path))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, file), data, 0o644); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func newHTTPClient(record bool) *http.Client {
+	client := &http.Client{
+		Timeout: time.Second * 10,
+	}
+	if record {
+		client.Transport = recordingTransport{next: http.DefaultTransport, dir: parityFixturesDir}
+	}
+	return client
+}
+
+func getDocumentStoreBody(t *testing.T, uuid string, client parityClient) string {
+	t.Helper()
+
+	url := client.documentStoreURL + uuid
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatalf("failed to create document store get request: %v", err)
+	}
+
+	req.SetBasicAuth(client.basicAuthUser, client.basicAuthPassword)
+
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to perform document store get request: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("failed to get item '%s' from document store: '%s'", uuid, resp.Status)
+	}
+
+	content := struct {
+		BodyXML string `json:"bodyXML"`
+		Body    string `json:"body"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&content)
+	if err != nil {
+		t.Fatalf("failed to decode document store response body: %v", err)
+	}
+
+	if content.BodyXML != "" {
+		return content.BodyXML
+	}
+	if content.Body != "" {
+		return content.Body
+	}
+
+	t.Fatalf("no body or body xml fields returned from document store for uuid %v", uuid)
+	return ""
+}
+
+func getPublicContentBody(t *testing.T, uuid string, client parityClient) string {
+	t.Helper()
+
+	url := client.publicContentURL + uuid
+	if client.apiKey != "" {
+		url = fmt.Sprintf("%s?apiKey=%s", url, client.apiKey)
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatalf("failed to create public content get request: %v", err)
+	}
+
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to perform public content get request: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("failed to get item '%s' from public content api: '%s'", uuid, resp.Status)
+	}
+
+	content := struct {
+		BodyXML string `json:"bodyXML"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&content)
+	if err != nil {
+		t.Fatalf("failed to decode public content reponse: %v", err)
+	}
+
+	return content.BodyXML
+}
@@ -0,0 +1,120 @@
+package bodytransformer
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/beevik/etree"
+)
+
+// plainTextSkippedElements are the elements whose content is deleted by filters.DefaultContentFilters
+var plainTextSkippedElements = []string{"pull-quote", "web-pull-quote", "table", "promo-box", "web-inline-picture"}
+
+// plainText renders a body tree as the same plain text filters.DefaultContentFilters produces from the serialized
+// body: every tag is replaced by a whitespace, whitespace runs are collapsed to their last character and the text is
+// trimmed. Offsets into the text are counted in runes.
+type plainText struct {
+	runes []rune
+	// skip, when set, drops the elements for which it returns true in addition to plainTextSkippedElements
+	skip func(el *etree.Element) bool
+	// clean, when set, is applied to every text node before it is written
+	clean func(text string) string
+	// attachPunctuation drops the whitespace written after an element when the text following it starts with
+	// closing punctuation, e.g. the space before the full stop of "<em>text</em>."
+	attachPunctuation bool
+	// boundary is set while the text ends with the whitespace written after an element
+	boundary bool
+}
+
+// closingPunctuation is the punctuation attached to the text of the element it follows
+const closingPunctuation = ".,;:!?)]’”…"
+
+// render writes the text of the descendants of el, calling visit, when set, before and after writing each element
+func (p *plainText) render(el *etree.Element, visit func(el *etree.Element, start bool)) {
+	for _, token := range el.Child {
+		switch t := token.(type) {
+		case *etree.CharData:
+			p.writeText(t.Data)
+		case *etree.Element:
+			if p.skipped(t) {
+				continue
+			}
+			p.write(" ")
+			if visit != nil {
+				visit(t, true)
+			}
+			p.render(t, visit)
+			if visit != nil {
+				visit(t, false)
+			}
+			p.writeBoundary()
+		case *etree.Comment, *etree.Directive, *etree.ProcInst:
+			p.write(" ")
+		}
+	}
+}
+
+func (p *plainText) skipped(el *etree.Element) bool {
+	return contains(plainTextSkippedElements, el.Tag) || (p.skip != nil && p.skip(el))
+}
+
+// writeText writes the data of a text node
+func (p *plainText) writeText(s string) {
+	if p.clean != nil {
+		s = p.clean(s)
+	}
+	p.write(s)
+}
+
+// writeBoundary writes the whitespace replacing the end tag of an element
+func (p *plainText) writeBoundary() {
+	p.write(" ")
+	p.boundary = true
+}
+
+func (p *plainText) write(s string) {
+	for _, r := range s {
+		if p.boundary && p.attachPunctuation && strings.ContainsRune(closingPunctuation, r) && len(p.runes) > 0 &&
+			isASCIISpace(p.runes[len(p.runes)-1]) {
+			p.runes = p.runes[:len(p.runes)-1]
+		}
+		p.boundary = false
+		if isASCIISpace(r) && len(p.runes) > 0 && isASCIISpace(p.runes[len(p.runes)-1]) {
+			p.runes[len(p.runes)-1] = r
+			continue
+		}
+		p.runes = append(p.runes, r)
+	}
+}
+
+// offset returns the current position in the written text, before trimming and excluding trailing whitespace
+func (p *plainText) offset() int {
+	n := len(p.runes)
+	for n > 0 && isASCIISpace(p.runes[n-1]) {
+		n--
+	}
+	return n
+}
+
+// trimmedOffset converts a position in the written text to the position in the trimmed text
+func (p *plainText) trimmedOffset(offset int) int {
+	lead := len(p.runes) - len([]rune(strings.TrimLeftFunc(string(p.runes), unicode.IsSpace)))
+	length := len([]rune(p.String()))
+	return min(max(offset-lead, 0), length)
+}
+
+func (p *plainText) String() string {
+	return strings.TrimSpace(string(p.runes))
+}
+
+// isASCIISpace matches the whitespace characters of the \s class used by filters.DedupSpaces
+func isASCIISpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\f' || r == '\r'
+}
+
+// elementText returns the plain text of el and its descendants
+func elementText(el *etree.Element) string {
+	var p plainText
+	p.render(el, nil)
+	return p.String()
+}
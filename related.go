@@ -0,0 +1,160 @@
+package bodytransformer
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// relatedElements are the elements holding related content links
+var relatedElements = []string{"ft-related", "recommended"}
+
+// ftContentHosts are the hosts of the links to ft.com content, relative links are to ft.com too
+var ftContentHosts = []string{"ft.com", "www.ft.com"}
+
+// contentType is the type of the content linked by anchors, whose actual type is unknown
+const contentType = "http://www.ft.com/ontology/content/Content"
+
+// relatedDetailElements are the children of an ft-related element that are not part of its link text
+var relatedDetailElements = []string{"title", "headline", "intro", "media"}
+
+// RelatedItem is a link to related content found in an ft-related element or a recommended block of a body
+type RelatedItem struct {
+	// Element is the element the item was found in, ft-related or recommended
+	Element string
+	// Title is the title of the linked content: the title attribute of the link, the headline of an ft-related
+	// element or the link text
+	Title string
+	UUID  string
+	Type  string
+	// URL is the API url of the linked content, the href of the links to other sites
+	URL string
+	// Text is the link text
+	Text string
+	// Heading is the heading of the block holding the link: the recommended-title of a recommended block or the
+	// title of an ft-related element
+	Heading string
+}
+
+// ExtractRelated transforms the body, customised by opts, and returns it together with the related content links
+// of its ft-related elements and recommended blocks. The links are collected and the stripped blocks removed right
+// after the ft elements are renamed, before any other rule runs. The blocks inside stripped elements are ignored.
+func ExtractRelated(body string, opts ...Option) (string, []RelatedItem, error) {
+	doc, err := parseBody(body)
+	if err != nil {
+		return "", nil, err
+	}
+	cfg := newConfig(opts)
+	var items []RelatedItem
+	cfg.related = &items
+	if err := transformDocument(doc, cfg, &Report{}); err != nil {
+		return "", nil, err
+	}
+
+	strBody, err := writeBody(doc)
+	if err != nil {
+		return "", nil, err
+	}
+	return strBody, items, nil
+}
+
+// extractRelated returns the related content links of the document and removes their blocks when they are stripped
+func extractRelated(doc *etree.Document, stripped []string, report *Report) []RelatedItem {
+	var items []RelatedItem
+	var blocks []*etree.Element
+	for _, el := range doc.FindElements("//*") {
+		if !contains(relatedElements, el.Tag) || hasStrippedAncestor(el, stripped) {
+			continue
+		}
+		if el.Tag == "ft-related" {
+			items = append(items, relatedItem(el))
+		} else {
+			items = append(items, recommendedItems(el)...)
+		}
+		blocks = append(blocks, el)
+	}
+	for _, el := range blocks {
+		if contains(stripped, el.Tag) {
+			removeElement(el, RuleStrip, report)
+		}
+	}
+	return items
+}
+
+func hasStrippedAncestor(el *etree.Element, stripped []string) bool {
+	for p := el.Parent(); p != nil; p = p.Parent() {
+		if contains(stripped, p.Tag) && !contains(relatedElements, p.Tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func relatedItem(el *etree.Element) RelatedItem {
+	url := el.SelectAttrValue("url", "")
+	item := RelatedItem{
+		Element: el.Tag,
+		UUID:    uuidFromURL(url),
+		Type:    el.SelectAttrValue("type", ""),
+		URL:     url,
+	}
+	if title := el.SelectElement("title"); title != nil {
+		item.Heading = elementText(title)
+	}
+	if headline := el.SelectElement("headline"); headline != nil {
+		item.Text = elementText(headline)
+	} else {
+		// the text of the element outside of its title, intro and media
+		text := plainText{skip: func(child *etree.Element) bool {
+			return child.Parent() == el && contains(relatedDetailElements, child.Tag)
+		}}
+		text.render(el, nil)
+		item.Text = text.String()
+	}
+	item.Title = item.Text
+	return item
+}
+
+// recommendedItems returns the items of the links, ft-content or a, in the list items of a recommended block
+func recommendedItems(block *etree.Element) []RelatedItem {
+	var heading string
+	if title := block.SelectElement("recommended-title"); title != nil {
+		heading = elementText(title)
+	}
+
+	var items []RelatedItem
+	for _, li := range block.FindElements(".//li") {
+		for _, link := range li.ChildElements() {
+			var item RelatedItem
+			switch link.Tag {
+			case "ft-content":
+				url := link.SelectAttrValue("url", "")
+				item = RelatedItem{UUID: uuidFromURL(url), Type: link.SelectAttrValue("type", ""), URL: url}
+			case "a":
+				item = linkItem(link.SelectAttrValue("href", ""))
+			default:
+				continue
+			}
+			item.Element = block.Tag
+			item.Heading = heading
+			item.Text = elementText(link)
+			item.Title = link.SelectAttrValue("title", item.Text)
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// linkItem returns the item of a link to href, with the API url of the content when href is an ft.com content link
+func linkItem(href string) RelatedItem {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil || (u.Host != "" && !containsHost(ftContentHosts, u.Hostname())) {
+		return RelatedItem{URL: href}
+	}
+	uuid := uuidRegex.FindString(u.Path)
+	if uuid == "" {
+		return RelatedItem{URL: href}
+	}
+	return RelatedItem{UUID: uuid, Type: contentType, URL: getURLAttrValue(uuid, contentType)}
+}
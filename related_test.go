@@ -0,0 +1,146 @@
+package bodytransformer
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExtractRelated(t *testing.T) {
+	body := `<body><p>Intro</p>` +
+		`<related id="7a2b" type="http://www.ft.com/ontology/content/Article"><title>Read more</title>` +
+		`<headline>Markets  rally</headline><intro><p>Stocks rose</p></intro></related>` +
+		`<related id="9c1d" type="http://www.ft.com/ontology/content/Article"><media>Image</media> The <em>full</em> story</related>` +
+		`<recommended><recommended-title>Recommended</recommended-title><ul>` +
+		`<li><content id="0c0f" type="http://www.ft.com/ontology/content/Content">TikTok spied on me</content></li>` +
+		`<li><a href="https://www.ft.com/content/0c0f9670-2e3a-4af8-bcd5-85e314f6ac5e?segmentId=1" title="Why TikTok?">TikTok</a></li>` +
+		`<li><a href="https://example.com/news">Elsewhere</a></li>` +
+		`</ul></recommended><p>End</p></body>`
+
+	text, items, err := ExtractRelated(body)
+	if err != nil {
+		t.Fatalf("unexpected extraction error: %s", err.Error())
+	}
+	expectedText := "<body><p>Intro</p><p>End</p></body>"
+	if text != expectedText {
+		t.Fatalf("expected:\n%s\ngot:\n%s\n", expectedText, text)
+	}
+	expected := []RelatedItem{
+		{
+			Element: "ft-related",
+			Title:   "Markets rally",
+			UUID:    "7a2b",
+			Type:    "http://www.ft.com/ontology/content/Article",
+			URL:     "http://api.ft.com/content/7a2b",
+			Text:    "Markets rally",
+			Heading: "Read more",
+		},
+		{
+			Element: "ft-related",
+			Title:   "The full story",
+			UUID:    "9c1d",
+			Type:    "http://www.ft.com/ontology/content/Article",
+			URL:     "http://api.ft.com/content/9c1d",
+			Text:    "The full story",
+		},
+		{
+			Element: "recommended",
+			Title:   "TikTok spied on me",
+			UUID:    "0c0f",
+			Type:    "http://www.ft.com/ontology/content/Content",
+			URL:     "http://api.ft.com/content/0c0f",
+			Text:    "TikTok spied on me",
+			Heading: "Recommended",
+		},
+		{
+			Element: "recommended",
+			Title:   "Why TikTok?",
+			UUID:    "0c0f9670-2e3a-4af8-bcd5-85e314f6ac5e",
+			Type:    "http://www.ft.com/ontology/content/Content",
+			URL:     "http://api.ft.com/content/0c0f9670-2e3a-4af8-bcd5-85e314f6ac5e",
+			Text:    "TikTok",
+			Heading: "Recommended",
+		},
+		{
+			Element: "recommended",
+			Title:   "Elsewhere",
+			URL:     "https://example.com/news",
+			Text:    "Elsewhere",
+			Heading: "Recommended",
+		},
+	}
+	if !reflect.DeepEqual(expected, items) {
+		t.Fatalf("expected:\n%+v\ngot:\n%+v\n", expected, items)
+	}
+}
+
+func TestExtractRelatedWithOptions(t *testing.T) {
+	body := `<body><p>Intro</p><experimental><related id="5e6f" type="http://www.ft.com/ontology/content/Article">Hidden</related></experimental>` +
+		`<recommended><recommended-title>Recommended</recommended-title><ul>` +
+		`<li><content id="0c0f" type="http://www.ft.com/ontology/content/Content">TikTok spied on me</content></li>` +
+		`</ul></recommended><p>End</p></body>`
+
+	text, items, err := ExtractRelated(body, Sanitise(SanitiseOptions{}), HeadingAnchors(), UnwrapContainers(DefaultContainerRules...))
+	if err != nil {
+		t.Fatalf("unexpected extraction error: %s", err.Error())
+	}
+	expectedText := "<body><p>Intro</p><p>End</p></body>"
+	if text != expectedText {
+		t.Fatalf("expected:\n%s\ngot:\n%s\n", expectedText, text)
+	}
+	expected := []RelatedItem{{
+		Element: "recommended",
+		Title:   "TikTok spied on me",
+		UUID:    "0c0f",
+		Type:    "http://www.ft.com/ontology/content/Content",
+		URL:     "http://api.ft.com/content/0c0f",
+		Text:    "TikTok spied on me",
+		Heading: "Recommended",
+	}}
+	if !reflect.DeepEqual(expected, items) {
+		t.Fatalf("expected:\n%+v\ngot:\n%+v\n", expected, items)
+	}
+}
+
+func TestExtractRelatedKeepsBody(t *testing.T) {
+	tests := map[string][]Option{
+		"default":          nil,
+		"keep-recommended": {KeepElements("recommended")},
+	}
+	for _, dir := range fixtureDirs(t) {
+		body := readFile(t, filepath.Join(dir, "content.html"))
+		for name, opts := range tests {
+			expected, _, err := Transform(body, opts...)
+			if err != nil {
+				t.Fatalf("unexpected transformation error: %s", err.Error())
+			}
+			transformed, _, err := ExtractRelated(body, opts...)
+			if err != nil {
+				t.Fatalf("unexpected extraction error: %s", err.Error())
+			}
+			if transformed != expected {
+				t.Errorf("%s %s: expected:\n%s\ngot:\n%s\n", dir, name, expected, transformed)
+			}
+		}
+	}
+}
+
+func TestExtractRelatedFixture(t *testing.T) {
+	body := readFile(t, filepath.Join("testdata", "1bd99ff1-c8c3-4f28-b011-e2f8aeaba833", "content.html"))
+	_, items, err := ExtractRelated(body)
+	if err != nil {
+		t.Fatalf("unexpected extraction error: %s", err.Error())
+	}
+	expected := []RelatedItem{{
+		Element: "recommended",
+		Title:   "TikTok spied on me. Why?",
+		UUID:    "0c0f9670-2e3a-4af8-bcd5-85e314f6ac5e",
+		Type:    "http://www.ft.com/ontology/content/Content",
+		URL:     "http://api.ft.com/content/0c0f9670-2e3a-4af8-bcd5-85e314f6ac5e",
+		Text:    "TikTok spied on me. Why?",
+		Heading: "Recommended",
+	}}
+	if !reflect.DeepEqual(expected, items) {
+		t.Fatalf("expected:\n%+v\ngot:\n%+v\n", expected, items)
+	}
+}
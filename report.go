@@ -0,0 +1,82 @@
+package bodytransformer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// Names of the rules recorded in the transformation report
+const (
+	RuleRename            = "rename"
+	RuleScrollable        = "scrollable-extraction"
+	RuleRemoveResource    = "remove-resource"
+	RuleStrip             = "strip"
+	RuleRemoveTweet       = "remove-tweet"
+	RuleRemoveAsset       = "remove-asset"
+	RuleHeadingAnchor     = "heading-anchor"
+	RuleTypography        = "typography"
+	RuleSanitiseRemove    = "sanitise-remove"
+	RuleSanitiseUnwrap    = "sanitise-unwrap"
+	RuleSanitiseAttribute = "sanitise-attribute"
+	RuleUnwrapContainer   = "unwrap-container"
+	RuleConvertTweet      = "convert-tweet"
+	RuleUnwrapAsset       = "unwrap-asset"
+	RuleLinkAsset         = "link-asset"
+	RuleExpandImage       = "expand-image"
+	RuleSkipImage         = "skip-image"
+	RuleSimplifyTable     = "simplify-table"
+	RuleConvertBigNumber  = "convert-big-number"
+	RuleConvertPullQuote  = "convert-pull-quote"
+	RuleConvertTimeline   = "convert-timeline"
+	RuleRewriteLink       = "rewrite-link"
+	RuleRemoveID          = "remove-id"
+)
+
+// Action is a single change made to the body by a transformation rule
+type Action struct {
+	Rule    string `json:"rule"`
+	Element string `json:"element"`
+	Path    string `json:"path"`
+}
+
+// Report lists the changes made to a body by Transform, in the order they were made
+type Report struct {
+	Actions []Action `json:"actions"`
+}
+
+// Count returns the number of actions recorded for the given rule
+func (r *Report) Count(rule string) int {
+	count := 0
+	for _, a := range r.Actions {
+		if a.Rule == rule {
+			count++
+		}
+	}
+	return count
+}
+
+// String summarises the report as one line per rule and element with the number of times it was applied
+func (r *Report) String() string {
+	counts := map[string]int{}
+	for _, a := range r.Actions {
+		counts[a.Rule+" "+a.Element]++
+	}
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&sb, "%s %d\n", k, counts[k])
+	}
+	return sb.String()
+}
+
+func (r *Report) add(rule string, el *etree.Element) {
+	r.Actions = append(r.Actions, Action{Rule: rule, Element: el.Tag, Path: el.GetPath()})
+}
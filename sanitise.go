@@ -0,0 +1,231 @@
+package bodytransformer
+
+import (
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// DefaultSanitiseElements are the elements allowed by the sanitiser, with the attributes allowed on each of them
+// in addition to DefaultSanitiseGlobalAttributes
+var DefaultSanitiseElements = map[string][]string{
+	"body": nil, "p": nil, "br": nil, "hr": nil, "div": nil, "span": nil, "section": nil,
+	"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+	"a":  {"href", "rel", "target"},
+	"em": nil, "strong": nil, "b": nil, "i": nil, "u": nil, "s": nil, "sub": nil, "sup": nil, "small": nil,
+	"abbr": nil, "cite": nil, "q": {"cite"}, "time": {"datetime"}, "code": nil, "pre": nil,
+	"ul": nil, "ol": {"start", "reversed"}, "li": nil, "dl": nil, "dt": nil, "dd": nil,
+	"blockquote": {"cite"}, "footer": nil,
+	"figure": nil, "figcaption": nil, "img": {"src", "srcset", "sizes", "alt", "width", "height"},
+	"table": nil, "caption": nil, "thead": nil, "tbody": nil, "tfoot": nil, "tr": nil,
+	"th":         {"colspan", "rowspan", "scope"},
+	"td":         {"colspan", "rowspan"},
+	"ft-content": {"type", "url", "data-embedded"},
+	"ft-related": {"type", "url"},
+	"ft-concept": {"type", "url"},
+}
+
+// DefaultSanitiseGlobalAttributes are the attributes allowed on every allowed element
+var DefaultSanitiseGlobalAttributes = []string{"id", "class", "lang", "dir", "title"}
+
+// DefaultSanitiseRemovedElements are the disallowed elements removed together with their content,
+// the other disallowed elements are unwrapped
+var DefaultSanitiseRemovedElements = []string{
+	"script", "style", "iframe", "frame", "frameset", "object", "embed", "applet", "noscript", "template",
+	"form", "input", "button", "select", "textarea", "svg", "math", "link", "meta", "base",
+}
+
+// sanitiseRootWrapper is the element replacing a disallowed root element, so that the body stays a single element
+const sanitiseRootWrapper = "body"
+
+// DefaultURLSchemes are the schemes allowed in URL attributes. URLs without a scheme are always allowed.
+var DefaultURLSchemes = []string{"http", "https", "mailto"}
+
+// urlAttributes are the attributes holding URLs, checked against the allowed URL schemes
+var urlAttributes = []string{
+	"href", "src", "srcset", "cite", "url", "action", "formaction", "poster", "background", "data", "xlink:href",
+}
+
+// SanitiseOptions configures the Sanitise rule. Nil fields use the defaults, empty ones allow nothing.
+type SanitiseOptions struct {
+	// Elements maps the allowed elements to the attributes allowed on them, DefaultSanitiseElements when nil
+	Elements map[string][]string
+	// GlobalAttributes are allowed on every allowed element, DefaultSanitiseGlobalAttributes when nil
+	GlobalAttributes []string
+	// RemovedElements are the disallowed elements removed with their content, DefaultSanitiseRemovedElements when nil.
+	// The other disallowed elements are replaced with their content.
+	RemovedElements []string
+	// URLSchemes are the schemes allowed in the attributes holding URLs (href, src...), DefaultURLSchemes when nil
+	URLSchemes []string
+}
+
+// Sanitise makes the transformed body safe to insert into a page: disallowed elements are removed or unwrapped,
+// disallowed attributes, event handlers and URLs with disallowed schemes (javascript:, data:...) are removed, and
+// so are comments and processing instructions. It runs after all other rules and its changes are recorded in the
+// report.
+func Sanitise(opts SanitiseOptions) Option {
+	return func(cfg *config) {
+		cfg.sanitise = &opts
+	}
+}
+
+// sanitiser applies the sanitise rule to a document
+type sanitiser struct {
+	elements         map[string][]string
+	globalAttributes []string
+	removedElements  []string
+	urlSchemes       []string
+	report           *Report
+}
+
+func sanitise(doc *etree.Document, opts SanitiseOptions, report *Report) {
+	s := sanitiser{
+		elements:         opts.Elements,
+		globalAttributes: opts.GlobalAttributes,
+		removedElements:  opts.RemovedElements,
+		urlSchemes:       opts.URLSchemes,
+		report:           report,
+	}
+	if s.elements == nil {
+		s.elements = DefaultSanitiseElements
+	}
+	if s.globalAttributes == nil {
+		s.globalAttributes = DefaultSanitiseGlobalAttributes
+	}
+	if s.removedElements == nil {
+		s.removedElements = DefaultSanitiseRemovedElements
+	}
+	if s.urlSchemes == nil {
+		s.urlSchemes = DefaultURLSchemes
+	}
+
+	// the root element is never unwrapped, so that the body stays a single element,
+	// and the elements following it at the top level of the document are removed
+	s.sanitiseChildren(&doc.Element)
+	root := doc.Root()
+	for _, el := range doc.ChildElements() {
+		if el != root {
+			removeElement(el, RuleSanitiseRemove, s.report)
+		}
+	}
+	if root != nil {
+		s.sanitiseRoot(root)
+	}
+}
+
+// sanitiseRoot sanitises the root element of the document. A disallowed root becomes a sanitiseRootWrapper element,
+// emptied when it is one of the removed elements.
+func (s *sanitiser) sanitiseRoot(root *etree.Element) {
+	if _, ok := s.elements[root.Tag]; !ok && (root.Tag != sanitiseRootWrapper || root.Space != "") {
+		if contains(s.removedElements, root.Tag) {
+			s.report.add(RuleSanitiseRemove, root)
+			root.Child = nil
+		} else {
+			s.report.add(RuleSanitiseUnwrap, root)
+		}
+		root.Space = ""
+		root.Tag = sanitiseRootWrapper
+		root.Attr = nil
+	}
+	s.sanitiseChildren(root)
+	s.sanitiseAttributes(root)
+}
+
+func (s *sanitiser) sanitiseElement(el *etree.Element) {
+	s.sanitiseChildren(el)
+
+	if _, ok := s.elements[el.Tag]; !ok {
+		s.report.add(RuleSanitiseUnwrap, el)
+		unwrapElement(el)
+		return
+	}
+	s.sanitiseAttributes(el)
+}
+
+func (s *sanitiser) sanitiseChildren(el *etree.Element) {
+	for _, token := range append([]etree.Token(nil), el.Child...) {
+		switch t := token.(type) {
+		case *etree.Element:
+			if el.Parent() == nil {
+				// the top-level elements of the document are sanitised on their own
+				continue
+			}
+			if contains(s.removedElements, t.Tag) {
+				removeElement(t, RuleSanitiseRemove, s.report)
+				continue
+			}
+			s.sanitiseElement(t)
+		case *etree.Comment, *etree.Directive:
+			s.report.Actions = append(s.report.Actions, Action{Rule: RuleSanitiseRemove, Element: "#comment", Path: el.GetPath()})
+			el.RemoveChild(t)
+		case *etree.ProcInst:
+			// the XML declaration of the document is kept
+			if el.Parent() != nil || t.Target != "xml" {
+				s.report.Actions = append(s.report.Actions, Action{Rule: RuleSanitiseRemove, Element: "#procinst", Path: el.GetPath()})
+				el.RemoveChild(t)
+			}
+		}
+	}
+}
+
+func (s *sanitiser) sanitiseAttributes(el *etree.Element) {
+	allowed := s.elements[el.Tag]
+	for _, attr := range append([]etree.Attr(nil), el.Attr...) {
+		key := attr.FullKey()
+		switch {
+		case !contains(allowed, key) && !contains(s.globalAttributes, key),
+			strings.HasPrefix(strings.ToLower(key), "on"),
+			contains(urlAttributes, key) && !s.allowedURLs(key, attr.Value):
+			el.RemoveAttr(key)
+			s.report.Actions = append(s.report.Actions, Action{
+				Rule:    RuleSanitiseAttribute,
+				Element: el.Tag,
+				Path:    el.GetPath() + "/@" + key,
+			})
+		}
+	}
+}
+
+// allowedURLs reports whether the URLs in the value of the attribute all have an allowed scheme
+func (s *sanitiser) allowedURLs(key, value string) bool {
+	urls := []string{value}
+	if key == "srcset" {
+		urls = nil
+		for _, candidate := range strings.Split(value, ",") {
+			if fields := strings.Fields(candidate); len(fields) > 0 {
+				urls = append(urls, fields[0])
+			}
+		}
+	}
+	for _, u := range urls {
+		if scheme, ok := urlScheme(u); ok && !contains(s.urlSchemes, scheme) {
+			return false
+		}
+	}
+	return true
+}
+
+// urlScheme returns the lower case scheme of u, the way browsers read it: ignoring leading control characters and
+// spaces and the tabs and new lines inside it
+func urlScheme(u string) (string, bool) {
+	u = strings.TrimLeft(u, "\x00\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c\x0d\x0e\x0f"+
+		"\x10\x11\x12\x13\x14\x15\x16\x17\x18\x19\x1a\x1b\x1c\x1d\x1e\x1f ")
+	u = strings.NewReplacer("\t", "", "\n", "", "\r", "").Replace(u)
+	end := strings.IndexAny(u, ":/?#")
+	if end <= 0 || u[end] != ':' {
+		return "", false
+	}
+	return strings.ToLower(u[:end]), true
+}
+
+// unwrapElement replaces el with its content
+func unwrapElement(el *etree.Element) {
+	parent := el.Parent()
+	index := el.Index()
+	for _, token := range append([]etree.Token(nil), el.Child...) {
+		el.RemoveChild(token)
+		parent.InsertChildAt(index, token)
+		index++
+	}
+	parent.RemoveChild(el)
+}
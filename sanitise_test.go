@@ -0,0 +1,131 @@
+package bodytransformer
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSanitise(t *testing.T) {
+	tests := map[string]struct {
+		body     string
+		opts     SanitiseOptions
+		expected string
+		actions  map[string]int
+	}{
+		"removed elements": {
+			body:     `<body><p>text</p><script>alert(1)</script><iframe src="x">fallback</iframe></body>`,
+			expected: `<body><p>text</p></body>`,
+			actions:  map[string]int{RuleSanitiseRemove: 2},
+		},
+		"unwrapped elements": {
+			body:     `<body><p><marquee><font color="red">old</font> markup</marquee></p></body>`,
+			expected: `<body><p>old markup</p></body>`,
+			actions:  map[string]int{RuleSanitiseUnwrap: 2},
+		},
+		"attributes": {
+			body:     `<body onload="alert(1)"><p style="color:red" class="lead" onClick="alert(2)">text</p></body>`,
+			expected: `<body><p class="lead">text</p></body>`,
+			actions:  map[string]int{RuleSanitiseAttribute: 3},
+		},
+		"url schemes": {
+			body: `<body><a href="javascript:alert(1)">a</a><a href=" &#x0A;JAVA&#x09;SCRIPT:alert(1)">b</a>` +
+				`<a href="https://www.ft.com/">c</a><a href="#top">d</a><a href="page:1">e</a></body>`,
+			expected: `<body><a>a</a><a>b</a><a href="https://www.ft.com/">c</a><a href="#top">d</a><a>e</a></body>`,
+			actions:  map[string]int{RuleSanitiseAttribute: 3},
+		},
+		"comments": {
+			body:     `<body><p>text<!--[if IE]><script>alert(1)</script><![endif]--></p></body>`,
+			expected: `<body><p>text</p></body>`,
+			actions:  map[string]int{RuleSanitiseRemove: 1},
+		},
+		"custom allowlist": {
+			body: `<body><p><a href="tel:123" data-x="1">call</a> <video src="v.mp4">video</video></p></body>`,
+			opts: SanitiseOptions{
+				Elements:        map[string][]string{"body": nil, "p": nil, "a": {"href"}},
+				RemovedElements: []string{"video"},
+				URLSchemes:      []string{"tel"},
+			},
+			expected: `<body><p><a href="tel:123">call</a> </p></body>`,
+			actions:  map[string]int{RuleSanitiseRemove: 1, RuleSanitiseAttribute: 1},
+		},
+		"root is never unwrapped": {
+			body:     `<body><p>text</p></body>`,
+			opts:     SanitiseOptions{Elements: map[string][]string{"p": nil}},
+			expected: `<body><p>text</p></body>`,
+			actions:  map[string]int{},
+		},
+		"removed root": {
+			body:     `<script type="text/javascript">alert(1)</script>`,
+			expected: `<body/>`,
+			actions:  map[string]int{RuleSanitiseRemove: 1},
+		},
+		"unwrapped root": {
+			body:     `<marquee onstart="alert(1)"><p>text</p><iframe src="x"/></marquee>`,
+			expected: `<body><p>text</p></body>`,
+			actions:  map[string]int{RuleSanitiseUnwrap: 1, RuleSanitiseRemove: 1},
+		},
+		"trailing root": {
+			body:     `<body><p>x</p></body><script>alert(1)</script><p onclick="alert(2)">y</p>`,
+			expected: `<body><p>x</p></body>`,
+			actions:  map[string]int{RuleSanitiseRemove: 2},
+		},
+		"top-level processing instructions": {
+			body:     `<?xml version="1.0"?><?xml-stylesheet href="https://attacker.example/x.xsl"?><body><?pi x?><p>text</p></body>`,
+			expected: `<?xml version="1.0"?><body><p>text</p></body>`,
+			actions:  map[string]int{RuleSanitiseRemove: 2},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, report, err := Transform(test.body, Sanitise(test.opts))
+			if err != nil {
+				t.Fatalf("unexpected transformation error: %s", err.Error())
+			}
+			if got != test.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s\n", test.expected, got)
+			}
+			for _, rule := range []string{RuleSanitiseRemove, RuleSanitiseUnwrap, RuleSanitiseAttribute} {
+				if report.Count(rule) != test.actions[rule] {
+					t.Fatalf("expected %d %s actions, got report:\n%s\n", test.actions[rule], rule, report)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitiseXSSFixture(t *testing.T) {
+	got, report, err := Transform(readFile(t, "testdata/xss/content.html"), Sanitise(SanitiseOptions{}))
+	if err != nil {
+		t.Fatalf("unexpected transformation error: %s", err.Error())
+	}
+	for _, payload := range []string{"<script", "<iframe", "<svg", "<object", "<embed", "<form", "<meta", "<style", "javascript:", "vbscript:", "data:", " on", "style="} {
+		if strings.Contains(strings.ToLower(got), payload) {
+			t.Errorf("expected %q to be sanitised, got:\n%s\n", payload, got)
+		}
+	}
+	if len(report.Actions) == 0 {
+		t.Fatal("expected sanitise actions in the report")
+	}
+}
+
+func TestSanitiseKeepsFixtures(t *testing.T) {
+	for _, dir := range fixtureDirs(t) {
+		if strings.HasPrefix(filepath.Base(dir), "xss") {
+			continue
+		}
+		body := readFile(t, filepath.Join(dir, "content.html"))
+		expected, err := TransformBody(body)
+		if err != nil {
+			t.Fatalf("unexpected transformation error: %s", err.Error())
+		}
+		got, _, err := Transform(body, Sanitise(SanitiseOptions{}))
+		if err != nil {
+			t.Fatalf("unexpected transformation error: %s", err.Error())
+		}
+		if got != expected {
+			t.Errorf("%s: sanitising changed the body:\n%s", dir, unifiedDiff(expected, got))
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package bodytransformer
+
+import (
+	"github.com/beevik/etree"
+)
+
+// ScrollableOptions configures the SemanticScrollableBlocks rule
+type ScrollableOptions struct {
+	// Heading is the element the theme-style="2" paragraphs (names) become, h3 when empty
+	Heading string
+	// Subheading is the element the theme-style="3" paragraphs (roles) become, h4 when empty
+	Subheading string
+	// ImagePlaceholder, when set, is the element added at the start of a section in place of its ImageSet,
+	// with the type and url attributes of the ImageSet reference
+	ImagePlaceholder string
+}
+
+// SemanticScrollableBlocks turns scrollable blocks into semantic markup instead of flattening them into the body:
+// every scrollable-section becomes a section element, the theme-style 2 and 3 paragraphs become its heading and
+// subheading, and its ImageSet can be kept as a placeholder.
+func SemanticScrollableBlocks(opts ScrollableOptions) Option {
+	return func(cfg *config) {
+		if opts.Heading == "" {
+			opts.Heading = "h3"
+		}
+		if opts.Subheading == "" {
+			opts.Subheading = "h4"
+		}
+		cfg.scrollable = &opts
+	}
+}
+
+// scrollableSections replaces every scrollable-block with a section element per scrollable-section
+func scrollableSections(doc *etree.Document, opts ScrollableOptions, report *Report) {
+	for _, block := range doc.FindElements("//scrollable-block") {
+		report.add(RuleScrollable, block)
+		parent := block.Parent()
+		insertIndex := block.Index()
+
+		sections := block.FindElements(".//scrollable-section")
+		if len(sections) == 0 {
+			sections = []*etree.Element{block}
+		}
+		for _, s := range sections {
+			section := scrollableSection(s, opts)
+			if len(section.Child) == 0 {
+				continue
+			}
+			parent.InsertChildAt(insertIndex, section)
+			insertIndex++
+		}
+		parent.RemoveChild(block)
+	}
+}
+
+func scrollableSection(s *etree.Element, opts ScrollableOptions) *etree.Element {
+	section := etree.NewElement("section")
+	if opts.ImagePlaceholder != "" {
+		for _, image := range s.FindElements("./ft-content[@type='http://www.ft.com/ontology/content/ImageSet']") {
+			placeholder := section.CreateElement(opts.ImagePlaceholder)
+			for _, key := range []string{"type", "url"} {
+				if attr := image.SelectAttr(key); attr != nil {
+					placeholder.CreateAttr(key, attr.Value)
+				}
+			}
+		}
+	}
+
+	for _, text := range s.FindElements(".//scrollable-text") {
+		for _, el := range text.ChildElements() {
+			// nested scrollable-text elements are added on their own, after their parent's children
+			if el.Tag == "scrollable-text" {
+				continue
+			}
+			switch el.SelectAttrValue("theme-style", "") {
+			case "2":
+				el.Tag = opts.Heading
+			case "3":
+				el.Tag = opts.Subheading
+			}
+			el.RemoveAttr("theme-style")
+			section.AddChild(el)
+		}
+	}
+	return section
+}
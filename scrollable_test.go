@@ -0,0 +1,50 @@
+package bodytransformer
+
+import "testing"
+
+func TestSemanticScrollableBlocks(t *testing.T) {
+	image := `<content data-embedded="true" id="1" type="http://www.ft.com/ontology/content/ImageSet"/>`
+	tests := map[string]struct {
+		body     string
+		opts     ScrollableOptions
+		expected string
+	}{
+		"default elements": {
+			body: `<body><scrollable-block theme="1"><scrollable-section theme-display="2">` + image +
+				`<scrollable-text><p theme-style="2">Name</p><p theme-style="3">Role</p><p theme-style="1">Text</p></scrollable-text>` +
+				`</scrollable-section></scrollable-block><p>After</p></body>`,
+			expected: `<body><section><h3>Name</h3><h4>Role</h4><p>Text</p></section><p>After</p></body>`,
+		},
+		"custom elements and placeholder": {
+			body: `<body><scrollable-block><scrollable-section>` + image +
+				`<scrollable-text><p theme-style="2">Name</p><p theme-style="3">Role</p></scrollable-text></scrollable-section>` +
+				`<scrollable-section><scrollable-text><p>Second</p></scrollable-text></scrollable-section></scrollable-block></body>`,
+			opts: ScrollableOptions{Heading: "h2", Subheading: "strong", ImagePlaceholder: "figure"},
+			expected: `<body><section><figure type="http://www.ft.com/ontology/content/ImageSet" url="http://api.ft.com/content/1"/>` +
+				`<h2>Name</h2><strong>Role</strong></section><section><p>Second</p></section></body>`,
+		},
+		"block without sections": {
+			body:     `<body><scrollable-block><scrollable-text><p>Text</p><scrollable-text><p>Nested</p></scrollable-text></scrollable-text></scrollable-block></body>`,
+			expected: `<body><section><p>Text</p><p>Nested</p></section></body>`,
+		},
+		"empty sections are dropped": {
+			body:     `<body><p>Text</p><scrollable-block><scrollable-section>` + image + `</scrollable-section></scrollable-block></body>`,
+			expected: `<body><p>Text</p></body>`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, report, err := Transform(test.body, SemanticScrollableBlocks(test.opts))
+			if err != nil {
+				t.Fatalf("unexpected transformation error: %s", err.Error())
+			}
+			if got != test.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s\n", test.expected, got)
+			}
+			if report.Count(RuleScrollable) != 1 {
+				t.Fatalf("expected 1 scrollable action, got report:\n%s\n", report)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package bodytransformer
+
+import (
+	"github.com/Financial-Times/cm-body-transformer/filters"
+	"github.com/beevik/etree"
+)
+
+// blockElements are the elements whose text makes up a paragraph of the body
+var blockElements = []string{
+	"p", "h1", "h2", "h3", "h4", "h5", "h6", "li", "dt", "dd", "blockquote", "figcaption", "pre",
+}
+
+// Segment transforms the body, customised by opts, and returns its text as paragraphs, each split into sentences with
+// filters.Sentences. Paragraphs are made of the text of the block elements (p, headings, list items...) and of the
+// text between them. The text is the same filters.DefaultContentFilters produces, so elements dropped from the plain
+// text, like tables, are not part of any paragraph, except that no space is left between an inline element and the
+// punctuation following it.
+func Segment(body string, opts ...Option) ([][]string, error) {
+	doc, err := parseBody(body)
+	if err != nil {
+		return nil, err
+	}
+	if err := transformDocument(doc, newConfig(opts), &Report{}); err != nil {
+		return nil, err
+	}
+
+	s := newSegmenter(nil, nil)
+	s.walk(&doc.Element)
+	s.flush()
+
+	var paragraphs [][]string
+	for _, p := range s.paragraphs {
+		paragraphs = append(paragraphs, filters.Sentences(p.text))
+	}
+	return paragraphs, nil
+}
+
+// paragraph is the plain text of a paragraph of the body
+type paragraph struct {
+	text    string
+	heading bool
+	// emphasis are the spans of text in em/strong elements, offsets are counted in runes
+	emphasis []span
+}
+
+type span struct {
+	start int
+	end   int
+	tag   string
+}
+
+// segmenter splits a body tree into paragraphs
+type segmenter struct {
+	paragraphs []paragraph
+	skip       func(el *etree.Element) bool
+	clean      func(text string) string
+	// loose collects the text found outside of block elements, up to the next block element
+	loose *paragraphWriter
+}
+
+func newSegmenter(skip func(el *etree.Element) bool, clean func(text string) string) *segmenter {
+	s := &segmenter{skip: skip, clean: clean}
+	s.loose = s.newWriter()
+	return s
+}
+
+func (s *segmenter) newWriter() *paragraphWriter {
+	return &paragraphWriter{
+		text:   plainText{skip: s.skip, clean: s.clean, attachPunctuation: true},
+		starts: map[*etree.Element]int{},
+	}
+}
+
+func (s *segmenter) walk(el *etree.Element) {
+	for _, token := range el.Child {
+		switch t := token.(type) {
+		case *etree.CharData:
+			s.loose.text.writeText(t.Data)
+		case *etree.Element:
+			switch {
+			case s.loose.text.skipped(t):
+			case hasBlockDescendant(t):
+				s.flush()
+				s.walk(t)
+				s.flush()
+			case contains(blockElements, t.Tag):
+				s.flush()
+				w := s.newWriter()
+				w.text.render(t, w.visit)
+				s.add(w.paragraph(headingLevel(t) > 0))
+			default:
+				s.loose.text.write(" ")
+				s.loose.visit(t, true)
+				s.loose.text.render(t, s.loose.visit)
+				s.loose.visit(t, false)
+				s.loose.text.writeBoundary()
+			}
+		}
+	}
+}
+
+func (s *segmenter) flush() {
+	s.add(s.loose.paragraph(false))
+	s.loose = s.newWriter()
+}
+
+func (s *segmenter) add(p paragraph) {
+	if p.text != "" {
+		s.paragraphs = append(s.paragraphs, p)
+	}
+}
+
+// paragraphWriter renders the text of a paragraph, keeping track of its emphasised spans
+type paragraphWriter struct {
+	text     plainText
+	emphasis []span
+	starts   map[*etree.Element]int
+}
+
+func (w *paragraphWriter) visit(el *etree.Element, start bool) {
+	switch el.Tag {
+	case "em", "i", "strong", "b":
+	default:
+		return
+	}
+	if start {
+		w.starts[el] = len(w.text.runes)
+		return
+	}
+	w.emphasis = append(w.emphasis, span{start: w.starts[el], end: w.text.offset(), tag: el.Tag})
+}
+
+func (w *paragraphWriter) paragraph(heading bool) paragraph {
+	p := paragraph{text: w.text.String(), heading: heading}
+	for _, e := range w.emphasis {
+		e.start, e.end = w.text.trimmedOffset(e.start), w.text.trimmedOffset(e.end)
+		if e.end > e.start {
+			p.emphasis = append(p.emphasis, e)
+		}
+	}
+	return p
+}
+
+func hasBlockDescendant(el *etree.Element) bool {
+	for _, child := range el.ChildElements() {
+		if contains(blockElements, child.Tag) || hasBlockDescendant(child) {
+			return true
+		}
+	}
+	return false
+}
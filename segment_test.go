@@ -0,0 +1,32 @@
+package bodytransformer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSegment(t *testing.T) {
+	body := `<body><h2>Crypto in the U.S. market</h2>` +
+		`<p>Mr Stark, former head of the SEC’s internet enforcement division, spoke. “For crypto companies, it’s better to beg for forgiveness,” he said.</p>` +
+		`<p>Tether rose by a fifth to $82bn. Circle lost a third, i.e. <concept id="1" type="t">USDC</concept> fell to $30.5bn.</p>` +
+		`<table><tr><td>Skipped.</td></tr></table>` +
+		`<ul><li>First point. Second sentence.</li><li><p>Nested paragraph.</p></li></ul>` +
+		`Loose <em>text</em> here. More <em>loose</em> text.<p>After a <a href="x">link</a>.</p><p><br/></p></body>`
+
+	got, err := Segment(body)
+	if err != nil {
+		t.Fatalf("unexpected segmentation error: %s", err.Error())
+	}
+	expected := [][]string{
+		{"Crypto in the U.S. market"},
+		{"Mr Stark, former head of the SEC’s internet enforcement division, spoke.", "“For crypto companies, it’s better to beg for forgiveness,” he said."},
+		{"Tether rose by a fifth to $82bn.", "Circle lost a third, i.e. USDC fell to $30.5bn."},
+		{"First point.", "Second sentence."},
+		{"Nested paragraph."},
+		{"Loose text here.", "More loose text."},
+		{"After a link."},
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected:\n%q\ngot:\n%q\n", expected, got)
+	}
+}
@@ -0,0 +1,219 @@
+package bodytransformer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Financial-Times/cm-body-transformer/filters"
+	"github.com/beevik/etree"
+)
+
+// DefaultSectionBreak is the pause made between the sections of a body when SSMLOptions.SectionBreak is not set
+const DefaultSectionBreak = 750 * time.Millisecond
+
+// SayAsRule wraps the text matching Pattern in a say-as element with the given interpret-as and format attributes
+type SayAsRule struct {
+	Pattern     *regexp.Regexp
+	InterpretAs string
+	Format      string
+}
+
+const months = `(?:January|February|March|April|May|June|July|August|September|October|November|December)`
+
+// Built-in say-as rules for the amounts, percentages and dates written in FT copy
+var (
+	SayAsCurrency = SayAsRule{
+		Pattern:     regexp.MustCompile(`[£$€¥]\d+(?:,\d{3})*(?:\.\d+)?(?:bn|tn|m|k)?`),
+		InterpretAs: "currency",
+	}
+	SayAsPercentage = SayAsRule{
+		Pattern:     regexp.MustCompile(`\d+(?:\.\d+)?(?:%| per ?cent)`),
+		InterpretAs: "unit",
+	}
+	SayAsDateDMY = SayAsRule{
+		Pattern:     regexp.MustCompile(`\b\d{1,2} ` + months + ` \d{4}\b`),
+		InterpretAs: "date",
+		Format:      "dmy",
+	}
+	SayAsDateMDY = SayAsRule{
+		Pattern:     regexp.MustCompile(`\b` + months + ` \d{1,2}, \d{4}\b`),
+		InterpretAs: "date",
+		Format:      "mdy",
+	}
+)
+
+// DefaultSayAsRules are the say-as rules used when SSMLOptions.SayAs is nil
+var DefaultSayAsRules = []SayAsRule{SayAsCurrency, SayAsPercentage, SayAsDateDMY, SayAsDateMDY}
+
+// ssmlSkippedElements are the elements that make no sense in audio
+var ssmlSkippedElements = []string{"img", "picture", "figure", "video", "audio", "iframe", "script", "style", "noscript"}
+
+var rawURLRegex = regexp.MustCompile(`(?:https?://|www\.)\S+`)
+
+// SSMLOptions configures RenderSSML
+type SSMLOptions struct {
+	// SectionBreak is the pause made before every heading that does not start the body, DefaultSectionBreak when zero
+	SectionBreak time.Duration
+	// SayAs are the rules applied to the text of the sentences, DefaultSayAsRules are used when nil.
+	// Use an empty slice to apply none.
+	SayAs []SayAsRule
+	// Options customise the transformation of the body
+	Options []Option
+}
+
+// RenderSSML transforms the body and renders it as SSML for text-to-speech. Paragraphs and headings become p
+// elements of s sentences, em/i and strong/b text is emphasised and the say-as rules are applied to the sentences.
+// Embedded content, images and other elements that make no sense in audio are skipped, and so are raw URLs.
+func RenderSSML(body string, opts SSMLOptions) (string, error) {
+	doc, err := parseBody(body)
+	if err != nil {
+		return "", err
+	}
+	if err := transformDocument(doc, newConfig(opts.Options), &Report{}); err != nil {
+		return "", err
+	}
+
+	sectionBreak := opts.SectionBreak
+	if sectionBreak == 0 {
+		sectionBreak = DefaultSectionBreak
+	}
+	sayAs := opts.SayAs
+	if sayAs == nil {
+		sayAs = DefaultSayAsRules
+	}
+
+	s := newSegmenter(skipInAudio, func(text string) string {
+		return rawURLRegex.ReplaceAllString(text, " ")
+	})
+	s.walk(&doc.Element)
+	s.flush()
+
+	var sb strings.Builder
+	sb.WriteString("<speak>")
+	for i, p := range s.paragraphs {
+		if p.heading && i > 0 {
+			fmt.Fprintf(&sb, `<break time="%dms"/>`, sectionBreak.Milliseconds())
+		}
+		sb.WriteString("<p>")
+		offset := 0
+		for _, sentence := range filters.Sentences(p.text) {
+			idx := strings.Index(p.text[offset:], sentence)
+			start := utf8.RuneCountInString(p.text[:offset+idx])
+			offset += idx + len(sentence)
+			sb.WriteString("<s>")
+			writeSSMLSentence(&sb, sentence, start, p.emphasis, sayAs)
+			sb.WriteString("</s>")
+		}
+		sb.WriteString("</p>")
+	}
+	sb.WriteString("</speak>")
+	return sb.String(), nil
+}
+
+func skipInAudio(el *etree.Element) bool {
+	if contains(ssmlSkippedElements, el.Tag) {
+		return true
+	}
+	// embedded content has no text to read
+	return el.Tag == "ft-content" && el.SelectAttrValue("data-embedded", "") == "true"
+}
+
+// ssmlSpan is a span of a sentence wrapped in an SSML element, offsets are counted in runes from the sentence start
+type ssmlSpan struct {
+	start int
+	end   int
+	open  string
+	close string
+}
+
+// writeSSMLSentence writes the escaped sentence with its emphasised and say-as spans.
+// start is the offset of the sentence in the paragraph the emphasis spans refer to.
+func writeSSMLSentence(sb *strings.Builder, sentence string, start int, emphasis []span, sayAs []SayAsRule) {
+	runes := []rune(sentence)
+	var spans []ssmlSpan
+	for _, e := range emphasis {
+		from, to := max(e.start-start, 0), min(e.end-start, len(runes))
+		if from >= to {
+			continue
+		}
+		level := "moderate"
+		if e.tag == "strong" || e.tag == "b" {
+			level = "strong"
+		}
+		spans = append(spans, ssmlSpan{start: from, end: to, open: `<emphasis level="` + level + `">`, close: "</emphasis>"})
+	}
+	emphasisSpans := len(spans)
+
+	for _, rule := range sayAs {
+		for _, loc := range rule.Pattern.FindAllStringIndex(sentence, -1) {
+			m := ssmlSpan{
+				start: utf8.RuneCountInString(sentence[:loc[0]]),
+				end:   utf8.RuneCountInString(sentence[:loc[1]]),
+				close: "</say-as>",
+			}
+			m.open = `<say-as interpret-as="` + ssmlAttributeEscaper.Replace(rule.InterpretAs) + `"`
+			if rule.Format != "" {
+				m.open += ` format="` + ssmlAttributeEscaper.Replace(rule.Format) + `"`
+			}
+			m.open += ">"
+			// say-as spans must nest within the emphasised text and must not overlap other say-as spans
+			if !crossesAny(m, spans[:emphasisSpans]) && !overlapsAny(m, spans[emphasisSpans:]) {
+				spans = append(spans, m)
+			}
+		}
+	}
+
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+
+	var stack []ssmlSpan
+	next := 0
+	for pos := 0; pos <= len(runes); pos++ {
+		for len(stack) > 0 && stack[len(stack)-1].end == pos {
+			sb.WriteString(stack[len(stack)-1].close)
+			stack = stack[:len(stack)-1]
+		}
+		if pos == len(runes) {
+			break
+		}
+		for next < len(spans) && spans[next].start == pos {
+			sb.WriteString(spans[next].open)
+			stack = append(stack, spans[next])
+			next++
+		}
+		sb.WriteString(ssmlEscaper.Replace(string(runes[pos])))
+	}
+}
+
+var ssmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+var ssmlAttributeEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+func crossesAny(m ssmlSpan, spans []ssmlSpan) bool {
+	for _, s := range spans {
+		inside := m.start >= s.start && m.end <= s.end
+		outside := m.end <= s.start || m.start >= s.end
+		contained := s.start >= m.start && s.end <= m.end
+		if !inside && !outside && !contained {
+			return true
+		}
+	}
+	return false
+}
+
+func overlapsAny(m ssmlSpan, spans []ssmlSpan) bool {
+	for _, s := range spans {
+		if m.start < s.end && s.start < m.end {
+			return true
+		}
+	}
+	return false
+}
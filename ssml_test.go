@@ -0,0 +1,88 @@
+package bodytransformer
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestRenderSSML(t *testing.T) {
+	tests := map[string]struct {
+		body     string
+		opts     SSMLOptions
+		expected string
+	}{
+		"paragraphs and sentences": {
+			body:     `<body><p>Markets fell. Investors sold.</p><p>Mr Smith said so.</p></body>`,
+			expected: `<speak><p><s>Markets fell.</s><s>Investors sold.</s></p><p><s>Mr Smith said so.</s></p></speak>`,
+		},
+		"section breaks before headings": {
+			body:     `<body><h2>Intro</h2><p>First.</p><h2>Outlook</h2><p>Second.</p></body>`,
+			opts:     SSMLOptions{SectionBreak: time.Second},
+			expected: `<speak><p><s>Intro</s></p><p><s>First.</s></p><break time="1000ms"/><p><s>Outlook</s></p><p><s>Second.</s></p></speak>`,
+		},
+		"emphasis": {
+			body:     `<body><p>It was <em>very</em> <strong>bad news</strong> indeed. <b>Really.</b></p></body>`,
+			opts:     SSMLOptions{SayAs: []SayAsRule{}},
+			expected: `<speak><p><s>It was <emphasis level="moderate">very</emphasis> <emphasis level="strong">bad news</emphasis> indeed.</s><s><emphasis level="strong">Really.</emphasis></s></p></speak>`,
+		},
+		"default say-as rules": {
+			body: `<body><p>Revenue rose 12.5% to $82bn on 3 March 2023. Costs were £1,200 by January 5, 2024.</p></body>`,
+			expected: `<speak><p><s>Revenue rose <say-as interpret-as="unit">12.5%</say-as> to <say-as interpret-as="currency">$82bn</say-as> on <say-as interpret-as="date" format="dmy">3 March 2023</say-as>.</s>` +
+				`<s>Costs were <say-as interpret-as="currency">£1,200</say-as> by <say-as interpret-as="date" format="mdy">January 5, 2024</say-as>.</s></p></speak>`,
+		},
+		"say-as within emphasis": {
+			body:     `<body><p>Shares lost <strong>more than 20 per cent</strong> in a day.</p></body>`,
+			expected: `<speak><p><s>Shares lost <emphasis level="strong">more than <say-as interpret-as="unit">20 per cent</say-as></emphasis> in a day.</s></p></speak>`,
+		},
+		"say-as crossing emphasis is skipped": {
+			body:     `<body><p>It fell 20 <em>per cent today</em>.</p></body>`,
+			expected: `<speak><p><s>It fell 20 <emphasis level="moderate">per cent today</emphasis>.</s></p></speak>`,
+		},
+		"punctuation after inline elements": {
+			body:     `<body><p>It was <em>bad</em>, said <a href="https://www.ft.com/">the FT</a>. <strong>Really</strong>!</p></body>`,
+			opts:     SSMLOptions{SayAs: []SayAsRule{}},
+			expected: `<speak><p><s>It was <emphasis level="moderate">bad</emphasis>, said the FT.</s><s><emphasis level="strong">Really</emphasis>!</s></p></speak>`,
+		},
+		"escaped say-as attributes": {
+			body:     `<body><p>Call 020 now.</p></body>`,
+			opts:     SSMLOptions{SayAs: []SayAsRule{{Pattern: regexp.MustCompile(`\d{3}`), InterpretAs: `a"b`, Format: "<&>"}}},
+			expected: `<speak><p><s>Call <say-as interpret-as="a&quot;b" format="&lt;&amp;&gt;">020</say-as> now.</s></p></speak>`,
+		},
+		"custom say-as rules": {
+			body:     `<body><p>Call 020 7873 3000 now.</p></body>`,
+			opts:     SSMLOptions{SayAs: []SayAsRule{{Pattern: regexp.MustCompile(`\d{3} \d{4} \d{4}`), InterpretAs: "telephone"}}},
+			expected: `<speak><p><s>Call <say-as interpret-as="telephone">020 7873 3000</say-as> now.</s></p></speak>`,
+		},
+		"skipped elements and urls": {
+			body: `<body><p>See www.ft.com/markets for more &amp; read on.</p>` +
+				`<ft-content type="http://www.ft.com/ontology/content/ImageSet" url="http://api.ft.com/content/1" data-embedded="true"></ft-content>` +
+				`<figure><img src="a.jpg"/><figcaption>Caption</figcaption></figure>` +
+				`<pull-quote><pull-quote-text>Quote</pull-quote-text></pull-quote><p>End.</p></body>`,
+			expected: `<speak><p><s>See for more &amp; read on.</s></p><p><s>End.</s></p></speak>`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := RenderSSML(test.body, test.opts)
+			if err != nil {
+				t.Fatalf("unexpected rendering error: %s", err.Error())
+			}
+			if got != test.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s\n", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestRenderSSMLValidXML(t *testing.T) {
+	bodyXML := readFile(t, "testdata/c0ac9d59-2285-4efc-b786-355a10ff3661/content.html")
+	got, err := RenderSSML(bodyXML, SSMLOptions{})
+	if err != nil {
+		t.Fatalf("unexpected rendering error: %s", err.Error())
+	}
+	if _, err := parseBody(got); err != nil {
+		t.Fatalf("expected valid SSML, got %v:\n%s\n", err, got)
+	}
+}
@@ -0,0 +1,94 @@
+package bodytransformer
+
+import (
+	"math"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Financial-Times/cm-body-transformer/filters"
+	"github.com/beevik/etree"
+)
+
+// DefaultWordsPerMinute is the reading speed used by Stats.ReadingTime when no positive rate is given
+const DefaultWordsPerMinute = 200
+
+// Stats holds the statistics of a transformed body. Words, Characters and Sentences are counted in the plain text
+// of the body, the same text filters.DefaultContentFilters produces.
+type Stats struct {
+	Words      int
+	Characters int
+	Sentences  int
+	Paragraphs int
+	Headings   int
+	Links      int
+	// Content counts the ft-content references by their type
+	Content map[string]int
+	// Concepts counts the ft-concept mentions
+	Concepts int
+	// RemovedEmbeds counts the elements removed from the body by the transformation
+	RemovedEmbeds int
+}
+
+// ReadingTime estimates the time needed to read the body at the given words per minute rate,
+// DefaultWordsPerMinute is used when the rate is not positive
+func (s Stats) ReadingTime(wordsPerMinute int) time.Duration {
+	if wordsPerMinute <= 0 {
+		wordsPerMinute = DefaultWordsPerMinute
+	}
+	minutes := float64(s.Words) / float64(wordsPerMinute)
+	return time.Duration(math.Round(minutes * float64(time.Minute)))
+}
+
+// Analyze transforms the body, customised by opts, and returns the statistics of the transformed body
+func Analyze(body string, opts ...Option) (Stats, error) {
+	doc, err := parseBody(body)
+	if err != nil {
+		return Stats{}, err
+	}
+	report := &Report{}
+	if err := transformDocument(doc, newConfig(opts), report); err != nil {
+		return Stats{}, err
+	}
+
+	stats := Stats{Content: map[string]int{}}
+	var text plainText
+	text.render(&doc.Element, nil)
+	plain := text.String()
+	stats.Words = filters.CountWords(plain)
+	stats.Characters = utf8.RuneCountInString(plain)
+	stats.Sentences = len(filters.Sentences(plain))
+
+	countElements(&doc.Element, &stats)
+
+	for _, a := range report.Actions {
+		switch a.Rule {
+		case RuleStrip, RuleRemoveResource, RuleRemoveTweet, RuleRemoveAsset:
+			stats.RemovedEmbeds++
+		}
+	}
+	return stats, nil
+}
+
+func countElements(el *etree.Element, stats *Stats) {
+	for _, child := range el.ChildElements() {
+		switch child.Tag {
+		case "p":
+			if elementText(child) != "" {
+				stats.Paragraphs++
+			}
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			stats.Headings++
+		case "a":
+			if child.SelectAttr("href") != nil {
+				stats.Links++
+			}
+		case "ft-content":
+			stats.Content[child.SelectAttrValue("type", "")]++
+		case "ft-concept":
+			stats.Concepts++
+		}
+		if !contains(plainTextSkippedElements, child.Tag) {
+			countElements(child, stats)
+		}
+	}
+}
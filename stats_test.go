@@ -0,0 +1,68 @@
+package bodytransformer
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Financial-Times/cm-body-transformer/filters"
+)
+
+func TestAnalyze(t *testing.T) {
+	body := `<body><content data-embedded="true" id="1" type="http://www.ft.com/ontology/content/ImageSet"/>` +
+		`<h2>The heading</h2><p>Shares in <concept id="2" type="http://www.ft.com/ontology/company/PublicCompany">Acme</concept> rose 2.5 per cent. ` +
+		`Read <content id="3" type="http://www.ft.com/ontology/content/Article">the story</content> or <a href="https://www.ft.com">the site</a>.</p>` +
+		`<p><br/></p><pull-quote><pull-quote-text><p>Quote</p></pull-quote-text></pull-quote>` +
+		`<blockquote class="twitter-tweet"><p>tweet</p></blockquote><p>“Is it?” he asked.</p></body>`
+
+	got, err := Analyze(body)
+	if err != nil {
+		t.Fatalf("unexpected analysis error: %s", err.Error())
+	}
+	expected := Stats{
+		Words:         20,
+		Characters:    93,
+		Sentences:     3,
+		Paragraphs:    2,
+		Headings:      1,
+		Links:         1,
+		Content:       map[string]int{"http://www.ft.com/ontology/content/Article": 1},
+		Concepts:      1,
+		RemovedEmbeds: 3,
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected:\n%+v\ngot:\n%+v\n", expected, got)
+	}
+}
+
+func TestAnalyzeMatchesFilters(t *testing.T) {
+	for _, dir := range fixtureDirs(t) {
+		body := readFile(t, filepath.Join(dir, "content.html"))
+		transformed, err := TransformBody(body)
+		if err != nil {
+			t.Fatalf("unexpected transformation error: %s", err.Error())
+		}
+		plain := filters.Apply(transformed, filters.DefaultContentFilters()...)
+
+		stats, err := Analyze(body)
+		if err != nil {
+			t.Fatalf("unexpected analysis error: %s", err.Error())
+		}
+		if stats.Words != filters.CountWords(plain) || stats.Characters != utf8.RuneCountInString(plain) {
+			t.Errorf("%s: expected %d words and %d characters as counted in the filtered text, got %d and %d",
+				dir, filters.CountWords(plain), utf8.RuneCountInString(plain), stats.Words, stats.Characters)
+		}
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	stats := Stats{Words: 500}
+	if got := stats.ReadingTime(0); got != 150*time.Second {
+		t.Errorf("expected 2m30s at the default rate, got %s", got)
+	}
+	if got := stats.ReadingTime(250); got != 2*time.Minute {
+		t.Errorf("expected 2m at 250 words per minute, got %s", got)
+	}
+}
@@ -0,0 +1,351 @@
+package bodytransformer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/beevik/etree"
+)
+
+// tableCellAttributes are the attributes kept on the cells of simplified tables
+var tableCellAttributes = []string{"colspan", "rowspan"}
+
+// SimplifyTables keeps the tables of the body instead of stripping them, and simplifies them: layout and styling
+// attributes are removed, header rows are moved to a thead with th cells, the other rows to a tbody, and nested
+// tables are dropped.
+func SimplifyTables() Option {
+	return func(cfg *config) {
+		cfg.simplifyTables = true
+		KeepElements("table")(cfg)
+	}
+}
+
+// simplifyTables simplifies every table of the document that is not nested in another table
+func simplifyTables(doc *etree.Document, report *Report) {
+	for _, table := range doc.FindElements("//table") {
+		if hasAncestor(table, "table") {
+			continue
+		}
+		report.add(RuleSimplifyTable, table)
+		for _, nested := range table.FindElements(".//table") {
+			// nested tables of a removed nested table are already detached
+			if isDescendant(nested, table) {
+				removeElement(nested, RuleSimplifyTable, report)
+			}
+		}
+		simplifyTable(table)
+	}
+}
+
+func simplifyTable(table *etree.Element) {
+	var caption *etree.Element
+	var head, body, foot []*etree.Element
+	hasHead := false
+	for _, child := range table.ChildElements() {
+		switch child.Tag {
+		case "caption":
+			if caption == nil {
+				caption = child
+			}
+		case "thead":
+			hasHead = true
+			head = append(head, child.SelectElements("tr")...)
+		case "tbody":
+			body = append(body, child.SelectElements("tr")...)
+		case "tfoot":
+			foot = append(foot, child.SelectElements("tr")...)
+		case "tr":
+			body = append(body, child)
+		}
+	}
+	// without a thead, the leading rows made of th cells are the header rows
+	if !hasHead {
+		for len(body) > 0 && isHeaderRow(body[0]) {
+			head = append(head, body[0])
+			body = body[1:]
+		}
+	}
+
+	table.Attr = nil
+	table.Child = nil
+	if caption != nil {
+		caption.Attr = nil
+		table.AddChild(caption)
+	}
+	for _, section := range []struct {
+		tag  string
+		rows []*etree.Element
+	}{{"thead", head}, {"tbody", body}, {"tfoot", foot}} {
+		if len(section.rows) == 0 {
+			continue
+		}
+		el := table.CreateElement(section.tag)
+		for _, row := range section.rows {
+			el.AddChild(simplifyRow(row, section.tag == "thead"))
+		}
+	}
+}
+
+// simplifyRow returns the row with its cells only, turned into th cells when header is set
+func simplifyRow(row *etree.Element, header bool) *etree.Element {
+	simplified := etree.NewElement("tr")
+	for _, cell := range row.ChildElements() {
+		if cell.Tag != "td" && cell.Tag != "th" {
+			continue
+		}
+		if header {
+			cell.Tag = "th"
+		}
+		attrs := cell.Attr
+		cell.Attr = nil
+		for _, attr := range attrs {
+			if contains(tableCellAttributes, attr.Key) {
+				cell.CreateAttr(attr.Key, attr.Value)
+			}
+		}
+		simplified.AddChild(cell)
+	}
+	return simplified
+}
+
+func isHeaderRow(row *etree.Element) bool {
+	cells := 0
+	for _, cell := range row.ChildElements() {
+		switch cell.Tag {
+		case "th":
+			cells++
+		case "td":
+			return false
+		}
+	}
+	return cells > 0
+}
+
+func hasAncestor(el *etree.Element, tag string) bool {
+	for p := el.Parent(); p != nil; p = p.Parent() {
+		if p.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func isDescendant(el, ancestor *etree.Element) bool {
+	for p := el.Parent(); p != nil; p = p.Parent() {
+		if p == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// Table is the text of a simplified table of the body
+type Table struct {
+	Caption string
+	// Header are the header rows of the table, each a list of cell texts
+	Header [][]string
+	// Rows are the other rows of the table, each a list of cell texts.
+	// A cell spanning several columns is followed by empty cells, up to 1000 columns.
+	Rows [][]string
+}
+
+// ExtractTables transforms the body, customised by opts, with SimplifyTables and returns the text of its tables
+func ExtractTables(body string, opts ...Option) ([]Table, error) {
+	doc, err := parseBody(body)
+	if err != nil {
+		return nil, err
+	}
+	cfg := newConfig(opts)
+	SimplifyTables()(cfg)
+	if err := transformDocument(doc, cfg, &Report{}); err != nil {
+		return nil, err
+	}
+
+	var tables []Table
+	for _, el := range doc.FindElements("//table") {
+		t := Table{}
+		if caption := el.SelectElement("caption"); caption != nil {
+			t.Caption = cellText(caption)
+		}
+		for _, section := range el.ChildElements() {
+			for _, row := range section.SelectElements("tr") {
+				cells := rowText(row)
+				if section.Tag == "thead" {
+					t.Header = append(t.Header, cells)
+				} else {
+					t.Rows = append(t.Rows, cells)
+				}
+			}
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// maxColspan is the largest colspan browsers honour, larger values are clamped to it
+const maxColspan = 1000
+
+func rowText(row *etree.Element) []string {
+	var cells []string
+	for _, cell := range row.ChildElements() {
+		cells = append(cells, cellText(cell))
+		if span, err := strconv.Atoi(cell.SelectAttrValue("colspan", "1")); err == nil {
+			for i := 1; i < min(span, maxColspan); i++ {
+				cells = append(cells, "")
+			}
+		}
+	}
+	return cells
+}
+
+// cellText returns the text of a table cell or caption on a single line, so that it fits in the rendered grids
+func cellText(el *etree.Element) string {
+	return singleLine(elementText(el))
+}
+
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// singleLine returns a copy of the table with the whitespace runs of its texts collapsed to a space
+func (t Table) singleLine() Table {
+	lines := func(rows [][]string) [][]string {
+		var collapsed [][]string
+		for _, row := range rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = singleLine(cell)
+			}
+			collapsed = append(collapsed, cells)
+		}
+		return collapsed
+	}
+	return Table{Caption: singleLine(t.Caption), Header: lines(t.Header), Rows: lines(t.Rows)}
+}
+
+var numericCellRegex = regexp.MustCompile(`^[-+−]?[£$€¥]?\d[\d,.]*(?:%|bn|tn|m|k|x|p)?$`)
+
+// columns returns the width in runes of each column of the table and whether it is numeric,
+// that is it has body cells and all the non-empty ones are numbers
+func (t Table) columns() (widths []int, numeric []bool) {
+	for _, row := range append(append([][]string(nil), t.Header...), t.Rows...) {
+		for len(widths) < len(row) {
+			widths = append(widths, 0)
+		}
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	numeric = make([]bool, len(widths))
+	nonNumeric := make([]bool, len(widths))
+	for _, row := range t.Rows {
+		for i, cell := range row {
+			switch {
+			case cell == "":
+			case numericCellRegex.MatchString(cell):
+				numeric[i] = true
+			default:
+				nonNumeric[i] = true
+			}
+		}
+	}
+	for i := range numeric {
+		numeric[i] = numeric[i] && !nonNumeric[i]
+	}
+	return widths, numeric
+}
+
+// Text renders the table as a plain text grid, with numeric columns aligned to the right.
+// Line breaks and other whitespace runs in the texts are rendered as a single space.
+func (t Table) Text() string {
+	t = t.singleLine()
+	widths, numeric := t.columns()
+	var sb strings.Builder
+	if t.Caption != "" {
+		sb.WriteString(t.Caption + "\n")
+	}
+	separator := func(fill string) {
+		for _, w := range widths {
+			sb.WriteString("+" + strings.Repeat(fill, w+2))
+		}
+		sb.WriteString("+\n")
+	}
+	row := func(cells []string) {
+		for i, w := range widths {
+			var cell string
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			pad := strings.Repeat(" ", w-utf8.RuneCountInString(cell))
+			if numeric[i] {
+				sb.WriteString("| " + pad + cell + " ")
+			} else {
+				sb.WriteString("| " + cell + pad + " ")
+			}
+		}
+		sb.WriteString("|\n")
+	}
+
+	separator("-")
+	if len(t.Header) > 0 {
+		for _, cells := range t.Header {
+			row(cells)
+		}
+		separator("=")
+	}
+	for _, cells := range t.Rows {
+		row(cells)
+	}
+	if len(t.Rows) > 0 {
+		separator("-")
+	}
+	return sb.String()
+}
+
+var markdownCellEscaper = strings.NewReplacer("|", `\|`)
+
+// Markdown renders the table as a Markdown pipe table, with numeric columns aligned to the right.
+// The header rows are joined into the single header row of the Markdown table, which is empty without header rows.
+// Line breaks and other whitespace runs in the texts are rendered as a single space.
+func (t Table) Markdown() string {
+	t = t.singleLine()
+	widths, numeric := t.columns()
+	var sb strings.Builder
+	if t.Caption != "" {
+		sb.WriteString(t.Caption + "\n\n")
+	}
+	row := func(cells []string) {
+		sb.WriteString("|")
+		for i := range widths {
+			var cell string
+			if i < len(cells) {
+				cell = markdownCellEscaper.Replace(cells[i])
+			}
+			sb.WriteString(" " + cell + " |")
+		}
+		sb.WriteString("\n")
+	}
+
+	header := make([]string, len(widths))
+	for _, cells := range t.Header {
+		for i, cell := range cells {
+			header[i] = strings.TrimSpace(header[i] + " " + cell)
+		}
+	}
+	row(header)
+	sb.WriteString("|")
+	for i := range widths {
+		if numeric[i] {
+			sb.WriteString(" ---: |")
+		} else {
+			sb.WriteString(" --- |")
+		}
+	}
+	sb.WriteString("\n")
+	for _, cells := range t.Rows {
+		row(cells)
+	}
+	return sb.String()
+}
@@ -0,0 +1,144 @@
+package bodytransformer
+
+import (
+	"reflect"
+	"testing"
+)
+
+const resultsTable = `<table class="data-table" style="width:100%" data-table-theme="auto"><caption class="o-table__caption">Quarterly results</caption>` +
+	`<colgroup><col width="50%"/></colgroup>` +
+	`<tr><th class="o-table__cell" data-column-type="string">Company</th><th align="right">Revenue</th><th>Change</th></tr>` +
+	`<tr><td class="o-table__cell">Coinbase</td><td align="right" style="color:red">$773m</td><td>-21.5%</td></tr>` +
+	`<tr><td>Gemini | EU</td><td>$1,200m</td><td><table><tr><td>nested</td></tr></table>4%</td></tr>` +
+	`<tr><td colspan="3">Source: company filings</td></tr></table>`
+
+func TestSimplifyTables(t *testing.T) {
+	tests := map[string]struct {
+		body     string
+		expected string
+	}{
+		"header rows and attributes": {
+			body: `<body><p>Results</p>` + resultsTable + `</body>`,
+			expected: `<body><p>Results</p><table><caption>Quarterly results</caption>` +
+				`<thead><tr><th>Company</th><th>Revenue</th><th>Change</th></tr></thead>` +
+				`<tbody><tr><td>Coinbase</td><td>$773m</td><td>-21.5%</td></tr>` +
+				`<tr><td>Gemini | EU</td><td>$1,200m</td><td>4%</td></tr>` +
+				`<tr><td colspan="3">Source: company filings</td></tr></tbody></table></body>`,
+		},
+		"existing sections": {
+			body: `<body><table border="1"><tfoot><tr><td>Total</td></tr></tfoot><thead><tr><td>Name</td></tr></thead>` +
+				`<tbody class="rows"><tr><th>Row header</th></tr></tbody></table></body>`,
+			expected: `<body><table><thead><tr><th>Name</th></tr></thead><tbody><tr><th>Row header</th></tr></tbody>` +
+				`<tfoot><tr><td>Total</td></tr></tfoot></table></body>`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, report, err := Transform(test.body, SimplifyTables())
+			if err != nil {
+				t.Fatalf("unexpected transformation error: %s", err.Error())
+			}
+			if got != test.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s\n", test.expected, got)
+			}
+			if report.Count(RuleStrip) != 0 || report.Count(RuleSimplifyTable) == 0 {
+				t.Fatalf("expected tables to be simplified, got report:\n%s\n", report)
+			}
+		})
+	}
+}
+
+func TestExtractTables(t *testing.T) {
+	tables, err := ExtractTables(`<body>` + resultsTable + `</body>`)
+	if err != nil {
+		t.Fatalf("unexpected extraction error: %s", err.Error())
+	}
+	expected := []Table{{
+		Caption: "Quarterly results",
+		Header:  [][]string{{"Company", "Revenue", "Change"}},
+		Rows: [][]string{
+			{"Coinbase", "$773m", "-21.5%"},
+			{"Gemini | EU", "$1,200m", "4%"},
+			{"Source: company filings", "", ""},
+		},
+	}}
+	if !reflect.DeepEqual(expected, tables) {
+		t.Fatalf("expected:\n%+v\ngot:\n%+v\n", expected, tables)
+	}
+
+	text := `Quarterly results
++-------------------------+---------+--------+
+| Company                 | Revenue | Change |
++=========================+=========+========+
+| Coinbase                |   $773m | -21.5% |
+| Gemini | EU             | $1,200m |     4% |
+| Source: company filings |         |        |
++-------------------------+---------+--------+
+`
+	if got := tables[0].Text(); got != text {
+		t.Fatalf("expected:\n%s\ngot:\n%s\n", text, got)
+	}
+
+	markdown := `Quarterly results
+
+| Company | Revenue | Change |
+| --- | ---: | ---: |
+| Coinbase | $773m | -21.5% |
+| Gemini \| EU | $1,200m | 4% |
+| Source: company filings |  |  |
+`
+	if got := tables[0].Markdown(); got != markdown {
+		t.Fatalf("expected:\n%s\ngot:\n%s\n", markdown, got)
+	}
+}
+
+func TestTableMarkdownWithoutHeader(t *testing.T) {
+	table := Table{Rows: [][]string{{"a", "1"}, {"b"}}}
+	expected := "|  |  |\n| --- | ---: |\n| a | 1 |\n| b |  |\n"
+	if got := table.Markdown(); got != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s\n", expected, got)
+	}
+}
+
+func TestExtractTablesColspan(t *testing.T) {
+	body := `<body><table><tr><td colspan="2000000000">wide</td><td colspan="0">zero</td><td colspan="-3">negative</td></tr></table></body>`
+	tables, err := ExtractTables(body)
+	if err != nil {
+		t.Fatalf("unexpected extraction error: %s", err.Error())
+	}
+	if len(tables) != 1 || len(tables[0].Rows) != 1 {
+		t.Fatalf("expected a single row, got %+v", tables)
+	}
+	row := tables[0].Rows[0]
+	if len(row) != maxColspan+2 || row[0] != "wide" || row[maxColspan] != "zero" || row[maxColspan+1] != "negative" {
+		t.Fatalf("expected the colspan to be clamped to %d columns, got %d cells", maxColspan, len(row))
+	}
+}
+
+func TestTableMultilineCells(t *testing.T) {
+	tables, err := ExtractTables("<body><table><caption>Two\nlines</caption><tr><th>Name</th><th>Value</th></tr>" +
+		"<tr><td>First<br/>line\n  second</td><td>1</td></tr></table></body>")
+	if err != nil {
+		t.Fatalf("unexpected extraction error: %s", err.Error())
+	}
+	expected := []Table{{Caption: "Two lines", Header: [][]string{{"Name", "Value"}}, Rows: [][]string{{"First line second", "1"}}}}
+	if !reflect.DeepEqual(expected, tables) {
+		t.Fatalf("expected:\n%+v\ngot:\n%+v\n", expected, tables)
+	}
+
+	table := Table{Header: [][]string{{"Name", "Value"}}, Rows: [][]string{{"First\nsecond", "1"}}}
+	markdown := "| Name | Value |\n| --- | ---: |\n| First second | 1 |\n"
+	if got := table.Markdown(); got != markdown {
+		t.Fatalf("expected:\n%s\ngot:\n%s\n", markdown, got)
+	}
+	text := `+--------------+-------+
+| Name         | Value |
++==============+=======+
+| First second |     1 |
++--------------+-------+
+`
+	if got := table.Text(); got != text {
+		t.Fatalf("expected:\n%s\ngot:\n%s\n", text, got)
+	}
+}
@@ -0,0 +1,132 @@
+package bodytransformer
+
+import (
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+// timelineElements are the tag names of the timelines converted by ConvertTimelines
+var timelineElements = []string{"timeline", "ft-timeline"}
+
+// timelineDateLayouts are the date formats of the timeline dates turned into a datetime attribute,
+// each with the layout of the attribute value keeping the precision of the date
+var timelineDateLayouts = []struct{ date, datetime string }{
+	{"2006-01-02", "2006-01-02"},
+	{"2 January 2006", "2006-01-02"},
+	{"2 Jan 2006", "2006-01-02"},
+	{"January 2 2006", "2006-01-02"},
+	{"January 2, 2006", "2006-01-02"},
+	{"Jan 2 2006", "2006-01-02"},
+	{"Jan 2, 2006", "2006-01-02"},
+	{"2006-01", "2006-01"},
+	{"January 2006", "2006-01"},
+	{"Jan 2006", "2006-01"},
+	{"2006", "2006"},
+}
+
+// TimelineOptions configures the ConvertTimelines rule
+type TimelineOptions struct {
+	// Heading is the element the timeline title becomes, h3 when empty
+	Heading string
+}
+
+// ConvertTimelines keeps the timelines of the body instead of stripping them, as a heading with the timeline title
+// followed by an ordered list of the events. Each list item holds a time element with the event date, the event
+// heading in strong and the event text. The byline and image of the timeline are dropped.
+func ConvertTimelines(opts TimelineOptions) Option {
+	return func(cfg *config) {
+		if opts.Heading == "" {
+			opts.Heading = "h3"
+		}
+		cfg.timelines = &opts
+		KeepElements(timelineElements...)(cfg)
+	}
+}
+
+// convertTimelines replaces every timeline of the document with a heading and an ordered list
+func convertTimelines(doc *etree.Document, opts TimelineOptions, report *Report) {
+	for _, name := range timelineElements {
+		for _, el := range doc.FindElements("//" + name) {
+			// timelines nested in a converted timeline are already detached
+			if el.Parent() == nil || hasAncestor(el, "timeline") || hasAncestor(el, "ft-timeline") {
+				continue
+			}
+			list := etree.NewElement("ol")
+			for _, event := range el.ChildElements() {
+				if event.Tag != "timeline-item" {
+					continue
+				}
+				if item := timelineItem(event); item != nil {
+					list.AddChild(item)
+				}
+			}
+			// a timeline without events is removed
+			if len(list.Child) == 0 {
+				removeElement(el, RuleConvertTimeline, report)
+				continue
+			}
+			report.add(RuleConvertTimeline, el)
+			parent := el.Parent()
+			index := el.Index()
+			if title := el.SelectElement("timeline-title"); title != nil && elementText(title) != "" {
+				heading := etree.NewElement(opts.Heading)
+				addInlineContent(heading, title)
+				parent.InsertChildAt(index, heading)
+				index++
+			}
+			parent.InsertChildAt(index, list)
+			parent.RemoveChild(el)
+		}
+	}
+}
+
+// timelineItem returns the list item of a timeline-item event, built from its timeline-date, timeline-heading and
+// timeline-body. It returns nil when the event has no date, heading or text.
+func timelineItem(event *etree.Element) *etree.Element {
+	item := etree.NewElement("li")
+	if date := event.SelectElement("timeline-date"); date != nil && elementText(date) != "" {
+		item.AddChild(timelineTime(date))
+	}
+	if heading := event.SelectElement("timeline-heading"); heading != nil && elementText(heading) != "" {
+		if len(item.Child) > 0 {
+			item.CreateText(" ")
+		}
+		addInlineContent(item.CreateElement("strong"), heading)
+	}
+	if body := event.SelectElement("timeline-body"); body != nil {
+		if hasBlockDescendant(body) {
+			for _, child := range body.ChildElements() {
+				item.AddChild(child)
+			}
+		} else if elementText(body) != "" {
+			addInlineContent(item.CreateElement("p"), body)
+		}
+	}
+	if len(item.Child) == 0 {
+		return nil
+	}
+	return item
+}
+
+// timelineTime returns the time element of a timeline date, with a datetime attribute when the date is recognised
+func timelineTime(date *etree.Element) *etree.Element {
+	t := etree.NewElement("time")
+	if datetime := parseTimelineDate(elementText(date)); datetime != "" {
+		t.CreateAttr("datetime", datetime)
+	}
+	addInlineContent(t, date)
+	return t
+}
+
+// parseTimelineDate returns the machine readable value of a timeline date, empty when the date is not recognised
+func parseTimelineDate(date string) string {
+	date = strings.TrimSuffix(strings.TrimSpace(date), ":")
+	for _, layout := range timelineDateLayouts {
+		if parsed, err := time.Parse(layout.date, date); err == nil {
+			return parsed.Format(layout.datetime)
+		}
+	}
+	return ""
+}
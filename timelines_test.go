@@ -0,0 +1,73 @@
+package bodytransformer
+
+import "testing"
+
+func TestConvertTimelines(t *testing.T) {
+	tests := map[string]struct {
+		opts     TimelineOptions
+		body     string
+		expected string
+	}{
+		"title and events": {
+			body: `<body><ft-timeline><timeline-title> Key dates </timeline-title><timeline-byline>FT</timeline-byline>` +
+				`<timeline-item><timeline-date>2008</timeline-date><timeline-heading>Crisis</timeline-heading>` +
+				`<timeline-body><p>Lehman <em>collapses</em></p></timeline-body></timeline-item>` +
+				`<timeline-item><timeline-date>June 2016</timeline-date><timeline-body>Brexit vote</timeline-body></timeline-item>` +
+				`</ft-timeline></body>`,
+			expected: `<body><h3>Key dates</h3><ol>` +
+				`<li><time datetime="2008">2008</time> <strong>Crisis</strong><p>Lehman <em>collapses</em></p></li>` +
+				`<li><time datetime="2016-06">June 2016</time><p>Brexit vote</p></li></ol></body>`,
+		},
+		"events without heading or text": {
+			body: `<body><p>Text</p><timeline><timeline-item><timeline-date>Spring 2021</timeline-date>` +
+				`<timeline-heading>Launch</timeline-heading></timeline-item>` +
+				`<timeline-item><timeline-heading>Landing</timeline-heading><timeline-body> </timeline-body></timeline-item></timeline></body>`,
+			expected: `<body><p>Text</p><ol><li><time>Spring 2021</time> <strong>Launch</strong></li>` +
+				`<li><strong>Landing</strong></li></ol></body>`,
+		},
+		"custom heading": {
+			opts: TimelineOptions{Heading: "h2"},
+			body: `<body><timeline><timeline-title>Dates</timeline-title>` +
+				`<timeline-item><timeline-date>Soon</timeline-date></timeline-item></timeline></body>`,
+			expected: `<body><h2>Dates</h2><ol><li><time>Soon</time></li></ol></body>`,
+		},
+		"timeline without events": {
+			body:     `<body><p>Text</p><timeline><timeline-title>Dates</timeline-title><timeline-item/></timeline></body>`,
+			expected: `<body><p>Text</p></body>`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, report, err := Transform(test.body, ConvertTimelines(test.opts))
+			if err != nil {
+				t.Fatalf("unexpected transformation error: %s", err.Error())
+			}
+			if got != test.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s\n", test.expected, got)
+			}
+			if report.Count(RuleConvertTimeline) != 1 || report.Count(RuleStrip) != 0 {
+				t.Fatalf("expected the timeline to be converted, got report:\n%s\n", report)
+			}
+		})
+	}
+}
+
+func TestParseTimelineDate(t *testing.T) {
+	tests := map[string]string{
+		"2023-03-08":     "2023-03-08",
+		"8 March 2023":   "2023-03-08",
+		"8 Mar 2023":     "2023-03-08",
+		"March 8, 2023":  "2023-03-08",
+		"Mar 8 2023:":    "2023-03-08",
+		"March 2023":     "2023-03",
+		" 2023 ":         "2023",
+		"Spring 2023":    "",
+		"March 32, 2023": "",
+	}
+	for date, expected := range tests {
+		if got := parseTimelineDate(date); got != expected {
+			t.Errorf("%q: expected %q, got %q", date, expected, got)
+		}
+	}
+}
@@ -1,75 +1,177 @@
 package bodytransformer
 
 import (
+	"context"
 	"fmt"
-	"html"
 	"regexp"
+	"strings"
 
 	"github.com/beevik/etree"
 )
 
-// TransformBody transforms content body in format presentable for external/non-FT consumers of the content
+// TransformBody transforms content body in format presentable for external/non-FT consumers of the content.
+// The transformation is idempotent, transforming an already transformed body returns it unchanged.
 func TransformBody(body string) (string, error) {
+	strBody, _, err := Transform(body)
+	return strBody, err
+}
+
+// Transform transforms content body the same way as TransformBody, with the default rules customised by opts.
+// The returned report lists the changes made to the body.
+func Transform(body string, opts ...Option) (string, *Report, error) {
+	return TransformContext(context.Background(), body, opts...)
+}
+
+// TransformContext transforms content body the same way as Transform, ctx is passed to the lookups made by the rules,
+// e.g. to the ImageResolver of Images
+func TransformContext(ctx context.Context, body string, opts ...Option) (string, *Report, error) {
+	cfg := newConfig(opts)
+	cfg.ctx = ctx
+	report := &Report{}
+
+	doc, err := parseBody(body)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := transformDocument(doc, cfg, report); err != nil {
+		return "", nil, err
+	}
+
+	strBody, err := writeBody(doc)
+	if err != nil {
+		return "", nil, err
+	}
+	return strBody, report, nil
+}
+
+func parseBody(body string) (*etree.Document, error) {
 	doc := etree.NewDocument()
 
 	err := doc.ReadFromString(body)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse body as xml: %w", err)
+		return nil, fmt.Errorf("failed to parse body as xml: %w", err)
+	}
+	return doc, nil
+}
+
+// transformDocument applies the transformation rules to the parsed body in place,
+// it fails when a lookup made by a rule fails
+func transformDocument(doc *etree.Document, cfg *config, report *Report) error {
+	if cfg.err != nil {
+		return cfg.err
 	}
 
 	// Find all tags with name "content" and replace their name with "ft-content", transform element attributes
 	for _, el := range doc.FindElements("//content") {
+		report.add(RuleRename, el)
 		el.Tag = "ft-content"
 		transformElementAttributes(el)
 	}
 
 	// Find all tags with name "related" and replace their name with "ft-related", transform element attributes
 	for _, el := range doc.FindElements("//related") {
+		report.add(RuleRename, el)
 		el.Tag = "ft-related"
 		transformElementAttributes(el)
 	}
 
 	// Find all tags with name "concept" and replace their name with "ft-concept", transform element attributes
 	for _, el := range doc.FindElements("//concept") {
+		report.add(RuleRename, el)
 		el.Tag = "ft-concept"
 		transformElementAttributes(el)
 	}
 
-	scrollableTextExtraction(doc)
-	removeFTContentResources(doc)
+	// Find all ft elements still carrying the internal id attribute and transform their attributes,
+	// the ones that already have an url were transformed before and only lose the id
+	for _, name := range []string{"ft-content", "ft-related", "ft-concept"} {
+		for _, el := range doc.FindElements("//" + name + "[@id]") {
+			if isTransformed(el) {
+				report.add(RuleRemoveID, el)
+				el.RemoveAttr("id")
+				continue
+			}
+			report.add(RuleRename, el)
+			transformElementAttributes(el)
+		}
+	}
+
+	if cfg.related != nil {
+		*cfg.related = extractRelated(doc, cfg.strippedElements, report)
+	}
+
+	if cfg.scrollable != nil {
+		scrollableSections(doc, *cfg.scrollable, report)
+	} else {
+		scrollableTextExtraction(doc, report)
+	}
+	if cfg.images != nil {
+		if err := expandImages(cfg.ctx, doc, cfg.images, report); err != nil {
+			return err
+		}
+	}
+	removeFTContentResources(doc, report)
+	unwrapContainers(doc, cfg.containerRules, report)
 
-	// Remove elements with particular tag names -
-	// "pull-quote","promo-box","ft-related","timeline","ft-timeline","table","big-number","img"
-	elementsToStrip := []string{
-		"pull-quote", "promo-box", "ft-related", "timeline", "ft-timeline", "table", "big-number", "img",
-		"experimental",
-		"recommended",
+	if cfg.convertBigNumbers {
+		convertBigNumbers(doc, report)
 	}
-	for _, name := range elementsToStrip {
+	if cfg.convertPullQuotes {
+		convertPullQuotes(doc, report)
+	}
+	if cfg.timelines != nil {
+		convertTimelines(doc, *cfg.timelines, report)
+	}
+
+	// Remove elements with particular tag names, see defaultStrippedElements
+	for _, name := range cfg.strippedElements {
 		for _, el := range doc.FindElements(
 			"//" + name) {
-			p := el.Parent()
-			p.RemoveChild(el)
+			removeElement(el, RuleStrip, report)
 		}
 	}
 
-	// Remove blockquote elements with attribute "class" with value "twitter-tweet"
+	if cfg.simplifyTables {
+		simplifyTables(doc, report)
+	}
+
+	// Remove blockquote elements with attribute "class" with value "twitter-tweet", or convert them when configured
+	if cfg.tweets != nil {
+		convertTweets(doc, *cfg.tweets, report)
+	}
 	for _, el := range doc.FindElements("//blockquote[@class='twitter-tweet']") {
-		p := el.Parent()
-		p.RemoveChild(el)
+		removeElement(el, RuleRemoveTweet, report)
 	}
 
-	// Remove "a" elements with attribute "data-asset-type" with value "video"
-	for _, el := range doc.FindElements("//a[@data-asset-type='video']") {
-		p := el.Parent()
-		p.RemoveChild(el)
+	// Remove "a" elements with attribute "data-asset-type" with value "video" or "interactive-graphic",
+	// or apply the configured policies, see defaultAttributeRules
+	applyAttributeRules(doc, cfg.attributeRules, report)
+
+	if cfg.links != nil {
+		rewriteLinks(doc, *cfg.links, report)
 	}
-	// Remove "a" elements with attribute "data-asset-type" with value "interactive-graphic"
-	for _, el := range doc.FindElements("//a[@data-asset-type='interactive-graphic']") {
-		p := el.Parent()
-		p.RemoveChild(el)
+
+	if cfg.typography != nil {
+		normaliseTypography(doc, *cfg.typography, report)
 	}
 
+	if cfg.headingAnchors {
+		addHeadingAnchors(doc, report)
+	}
+
+	if cfg.sanitise != nil {
+		sanitise(doc, *cfg.sanitise, report)
+	}
+	return nil
+}
+
+// writeBody serializes the transformed document and applies the string level cleanups
+func writeBody(doc *etree.Document) (string, error) {
+	// Escape only the characters that have to be escaped for the output to stay valid XML: "&" and "<" everywhere
+	// and '"' in attribute values. If client of the library needs html escaping, this should be their responsibility
+	doc.WriteSettings.CanonicalText = true
+	doc.WriteSettings.CanonicalAttrVal = true
 	strBody, err := doc.WriteToString()
 	if err != nil {
 		return "", err
@@ -78,8 +180,8 @@ func TransformBody(body string) (string, error) {
 	// Apply specific rules to some combinations of tags
 	strBody = transformParagraphElements(strBody)
 
-	// Remove the html escape sequences, if client of the library needs html escaping, this should be their responsibility
-	strBody = html.UnescapeString(strBody)
+	// Remove the remaining escape sequences that are not required in text
+	strBody = textUnescaper.Replace(strBody)
 
 	// Remove empty lines from the output
 	strBody = removeEmptyLines(strBody)
@@ -87,6 +189,16 @@ func TransformBody(body string) (string, error) {
 	return strBody, nil
 }
 
+// textUnescaper unescapes "&gt;" except in "]]&gt;", which is not allowed in XML text
+var textUnescaper = strings.NewReplacer("]]&gt;", "]]&gt;", "&gt;", ">", "&#xD;", "\r")
+
+// removeElement removes el from its parent and records the removal in the report
+func removeElement(el *etree.Element, rule string, report *Report) {
+	report.add(rule, el)
+	p := el.Parent()
+	p.RemoveChild(el)
+}
+
 // transformElementAttributes makes specific transformations to internal ft elements attributes.
 // The type and url attributes are added to the end of the attribute list, where url is created based on the values of
 // the existing id and type attributes. The id attribute is removed if present.
@@ -103,23 +215,30 @@ func transformElementAttributes(contentTag *etree.Element) {
 	}
 }
 
+// isTransformed reports whether el is an ft element whose attributes were already transformed
+func isTransformed(el *etree.Element) bool {
+	return strings.HasPrefix(el.Tag, "ft-") && el.SelectAttr("url") != nil
+}
+
+// apiPaths maps the types of the ft elements to the path of their API url
+var apiPaths = map[string]string{
+	"http://www.ft.com/ontology/content/Article":        "content",
+	"http://www.ft.com/ontology/content/ImageSet":       "content",
+	"http://www.ft.com/ontology/content/MediaResource":  "content",
+	"http://www.ft.com/ontology/content/Video":          "content",
+	"http://www.ft.com/ontology/company/PublicCompany":  "organisations",
+	"http://www.ft.com/ontology/content/ContentPackage": "content",
+	"http://www.ft.com/ontology/content/Content":        "content",
+	"http://www.ft.com/ontology/content/Image":          "content",
+	"http://www.ft.com/ontology/content/DynamicContent": "content",
+	"http://www.ft.com/ontology/content/Graphic":        "content",
+	"http://www.ft.com/ontology/content/Audio":          "content",
+	"http://www.ft.com/ontology/content/Clip":           "content",
+	"http://www.ft.com/ontology/content/ClipSet":        "content",
+}
+
 func getURLAttrValue(uuid string, t string) string {
-	typeSubURL := map[string]string{
-		"http://www.ft.com/ontology/content/Article":        "content",
-		"http://www.ft.com/ontology/content/ImageSet":       "content",
-		"http://www.ft.com/ontology/content/MediaResource":  "content",
-		"http://www.ft.com/ontology/content/Video":          "content",
-		"http://www.ft.com/ontology/company/PublicCompany":  "organisations",
-		"http://www.ft.com/ontology/content/ContentPackage": "content",
-		"http://www.ft.com/ontology/content/Content":        "content",
-		"http://www.ft.com/ontology/content/Image":          "content",
-		"http://www.ft.com/ontology/content/DynamicContent": "content",
-		"http://www.ft.com/ontology/content/Graphic":        "content",
-		"http://www.ft.com/ontology/content/Audio":          "content",
-		"http://www.ft.com/ontology/content/Clip":           "content",
-		"http://www.ft.com/ontology/content/ClipSet":        "content",
-	}
-	return fmt.Sprintf("http://api.ft.com/%s/%s", typeSubURL[t], uuid)
+	return fmt.Sprintf("http://api.ft.com/%s/%s", apiPaths[t], uuid)
 }
 
 // transformParagraphElements apply very specific rules to <p> elements
@@ -142,25 +261,30 @@ func removeEmptyLines(input string) string {
 	return reLines.ReplaceAllString(input, "")
 }
 
-func scrollableTextExtraction(doc *etree.Document) {
+func scrollableTextExtraction(doc *etree.Document, report *Report) {
 	for _, block := range doc.FindElements("//scrollable-block") {
+		report.add(RuleScrollable, block)
 		parent := block.Parent()
 		insertIndex := block.Index()
 		texts := block.FindElements(".//scrollable-text")
 		for _, text := range texts {
 			children := text.ChildElements()
-			for childIdx, el := range children {
+			for _, el := range children {
+				// nested scrollable-text elements are extracted on their own, after their parent's children
+				if el.Tag == "scrollable-text" {
+					continue
+				}
 				el.RemoveAttr("theme-style")
-				parent.InsertChildAt(insertIndex+childIdx, el)
+				parent.InsertChildAt(insertIndex, el)
+				insertIndex++
 			}
-			insertIndex += len(children)
 		}
 		parent.RemoveChild(block)
 	}
 }
 
 // removeFTContentResources discards any ft-content that we don't want to send to clients.
-func removeFTContentResources(doc *etree.Document) {
+func removeFTContentResources(doc *etree.Document, report *Report) {
 	toRemove := []string{
 		"http://www.ft.com/ontology/content/ImageSet",
 		"http://www.ft.com/ontology/content/MediaResource",
@@ -169,8 +293,7 @@ func removeFTContentResources(doc *etree.Document) {
 	}
 	for _, contentType := range toRemove {
 		for _, t := range doc.FindElements("//ft-content[@type='" + contentType + "']") {
-			p := t.Parent()
-			p.RemoveChild(t)
+			removeElement(t, RuleRemoveResource, report)
 		}
 	}
 }
@@ -4,19 +4,16 @@
 package bodytransformer
 
 import (
-	"encoding/json"
 	"flag"
-	"fmt"
-	"net/http"
 	"os"
 	"testing"
-	"time"
 )
 
 var (
 	apiKey            = flag.String("apiKey", "", "API key for accessing CAPI, used for public content API")
 	basicAuthUser     = flag.String("basicAuthUser", "", "basic auth user for accessing UPP clusters, used for getting content from document store")
 	basicAuthPassword = flag.String("basicAuthPassword", "", "basic auth password for accessing UPP clusters, used for getting content from document store")
+	record            = flag.Bool("record", false, "save the document store and public content API responses as parity fixtures in testdata/parity")
 )
 
 func TestMain(m *testing.M) {
@@ -35,15 +32,24 @@ func TestMain(m *testing.M) {
 // E.g. "72eebb8e-0bf0-11e8-8eb7-42f857ea9f09", "83ad52a8-59a5-11e7-9bc8-8055f264aa8b", "6cf68edc-f686-11e9-9ef3-eca8fc8f2d65"
 // "5dc60b96-669c-11ea-800d-da70cff6e4d3", "f1dcb508-3bc7-11e7-ac89-b01cc67cfeec"
 //
-// - if the body of a content contains html escape sequences the current implementation html-unescapes them as opposed to
+// - if the body of a content contains html escape sequences the current implementation html-unescapes them, except for
+// "&amp;", "&lt;" and "&quot;" in attribute values which keep the output valid XML, as opposed to
 // the output of the public content API where only some characters are escaped; we are un-escaping them because if the user
 // of the library needs to escape all characters consistently, they will end up escaping the already escaped characters
 // them 2 times and would have invalid character sequence as a result
 // E.g. "2617b220-1d2b-430c-842d-ebcc5be7e169", "d28aa2c6-a598-11db-a4e0-0000779e2340", "261cd90e-b620-11df-a784-00144feabdc0"
 // "144e1502-3762-11e6-a780-b48ed7b6126f", "28d2f611-2e19-4c7a-8e19-06228f567cb8", "34905308-81a4-11e0-8a54-00144feabdc0"
+//
+// Both differences are allowlisted as knownDifferences. With -record the responses are saved in testdata/parity
+// so that TestBodyTransformationReplay can run the same comparison offline.
 func TestBodyTransformation(t *testing.T) {
-	httpClient := &http.Client{
-		Timeout: time.Second * 10,
+	client := parityClient{
+		httpClient:        newHTTPClient(*record),
+		documentStoreURL:  "https://upp-prod-delivery-eu.ft.com" + documentStorePath,
+		publicContentURL:  "https://api.ft.com" + publicContentPath,
+		apiKey:            *apiKey,
+		basicAuthUser:     *basicAuthUser,
+		basicAuthPassword: *basicAuthPassword,
 	}
 
 	// set of uuids from random time periods
@@ -78,102 +84,5 @@ func TestBodyTransformation(t *testing.T) {
 		"9dffdb8f-f00e-4305-a69a-158b845f6970",
 	}
 
-	for _, uuid := range testUUIDs {
-		publicAPIBody := getPublicContentBody(t, uuid, httpClient)
-		docStoreBody := getDocumentStoreBody(t, uuid, httpClient)
-
-		transfomedBody, err := TransformBody(docStoreBody)
-		if err != nil {
-			t.Fatalf("failed to transform content body: %v", err)
-		}
-
-		// Remove empty new lines for easier comparison later
-		publicAPIBody = removeEmptyLines(publicAPIBody)
-
-		if publicAPIBody != transfomedBody {
-			t.Errorf(
-				"for %s transformed body is different than the content body returned by public content API \n expected \n %s \n got \n %s \n",
-				uuid,
-				publicAPIBody,
-				transfomedBody,
-			)
-		}
-	}
-}
-
-func getDocumentStoreBody(t *testing.T, uuid string, client *http.Client) string {
-	t.Helper()
-
-	urlTempl := "https://upp-prod-delivery-eu.ft.com/__document-store-api/content/%s"
-	url := fmt.Sprintf(urlTempl, uuid)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		t.Fatalf("failed to create document store get request: %v", err)
-	}
-
-	req.SetBasicAuth(*basicAuthUser, *basicAuthPassword)
-
-	resp, err := client.Do(req)
-	if err != nil {
-		t.Fatalf("failed to perform document store get request: %v", err)
-	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("failed to get item '%s' from document store: '%s'", uuid, resp.Status)
-	}
-
-	content := struct {
-		BodyXML string `json:"bodyXML"`
-		Body    string `json:"body"`
-	}{}
-	err = json.NewDecoder(resp.Body).Decode(&content)
-	if err != nil {
-		t.Fatalf("failed to decode document store response body: %v", err)
-	}
-
-	if content.BodyXML != "" {
-		return content.BodyXML
-	}
-	if content.Body != "" {
-		return content.Body
-	}
-
-	t.Fatalf("no body or body xml fields returned from document store for uuid %v", uuid)
-	return ""
-}
-
-func getPublicContentBody(t *testing.T, uuid string, client *http.Client) string {
-	t.Helper()
-
-	urlTempl := "https://api.ft.com/content/%s?apiKey=%s"
-	url := fmt.Sprintf(urlTempl, uuid, *apiKey)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		t.Fatalf("failed to create public content get request: %v", err)
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		t.Fatalf("failed to perform public content get request: %v", err)
-	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("failed to get item '%s' from public content api: '%s'", uuid, resp.Status)
-	}
-
-	content := struct {
-		BodyXML string `json:"bodyXML"`
-	}{}
-	err = json.NewDecoder(resp.Body).Decode(&content)
-	if err != nil {
-		t.Fatalf("failed to decode public content reponse: %v", err)
-	}
-
-	return content.BodyXML
+	runParity(t, client, testUUIDs)
 }
@@ -3,40 +3,21 @@ package bodytransformer
 import (
 	"io"
 	"os"
-	"strings"
+	"path/filepath"
 	"testing"
 )
 
 func TestTransformBody(t *testing.T) {
-	tests := map[string]struct {
-		BodyXMLFixture  string
-		ExpectedFixture string
-	}{
-		"experimental": {
-			BodyXMLFixture:  "testdata/10979399-ba25-45b9-b85d-776c1b75bfea/content.html",
-			ExpectedFixture: "testdata/10979399-ba25-45b9-b85d-776c1b75bfea/expected.html",
-		},
-		"scrollable": {
-			BodyXMLFixture:  "testdata/c0ac9d59-2285-4efc-b786-355a10ff3661/content.html",
-			ExpectedFixture: "testdata/c0ac9d59-2285-4efc-b786-355a10ff3661/expected.html",
-		},
-		"recommended": {
-			BodyXMLFixture:  "testdata/1bd99ff1-c8c3-4f28-b011-e2f8aeaba833/content.html",
-			ExpectedFixture: "testdata/1bd99ff1-c8c3-4f28-b011-e2f8aeaba833/expected.html",
-		},
-	}
-
-	for name, test := range tests {
-		test := test
-		t.Run(name, func(t *testing.T) {
-			bodyXML := readFile(t, test.BodyXMLFixture)
-			expected := readFile(t, test.ExpectedFixture)
-			got, err := TransformBody(bodyXML)
-			if err != nil {
-				t.Fatalf("unexpected transformation error: %s", err.Error())
-			}
-			if strings.Compare(expected, got) != 0 {
-				t.Fatalf("expected:\n%s\ngot:\n%s\n", expected, got)
+	for _, dir := range fixtureDirs(t) {
+		dir := dir
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			bodyXML := readFile(t, filepath.Join(dir, "content.html"))
+			for _, output := range goldenOutputs() {
+				got, err := output.render(bodyXML)
+				if err != nil {
+					t.Fatalf("unexpected transformation error for %s: %s", output.file, err.Error())
+				}
+				checkGolden(t, filepath.Join(dir, output.file), got, output.required)
 			}
 		})
 	}
@@ -56,3 +37,67 @@ func readFile(t *testing.T, filename string) string {
 	}
 	return string(data)
 }
+
+func TestTransformOptions(t *testing.T) {
+	body := `<body><p>text</p><table><tr><td>1</td></tr></table><aside>note</aside><content id="1" type="http://www.ft.com/ontology/content/Article">link</content></body>`
+
+	got, report, err := Transform(body, KeepElements("table"), StripElements("aside"))
+	if err != nil {
+		t.Fatalf("unexpected transformation error: %s", err.Error())
+	}
+	expected := `<body><p>text</p><table><tr><td>1</td></tr></table><ft-content type="http://www.ft.com/ontology/content/Article" url="http://api.ft.com/content/1">link</ft-content></body>`
+	if got != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s\n", expected, got)
+	}
+	if report.Count(RuleStrip) != 1 || report.Count(RuleRename) != 1 {
+		t.Fatalf("unexpected report:\n%s", report)
+	}
+	if report.Actions[1].Element != "aside" || report.Actions[1].Path != "/body/aside" {
+		t.Fatalf("unexpected strip action: %+v", report.Actions[1])
+	}
+}
+
+func TestTransformBodyIdempotent(t *testing.T) {
+	bodies := map[string]string{
+		"escaped markup":       `<body><p>&lt;p&gt;not a paragraph&lt;/p&gt; &amp;lt; &quot;quoted&quot;</p><a href="x?a=1&amp;b=&quot;2&quot;">link</a></body>`,
+		"cdata end":            `<body><p>]]&gt;</p></body>`,
+		"transformed elements": `<body><p><ft-content id="1" type="http://www.ft.com/ontology/content/Article" url="http://api.ft.com/content/2">kept</ft-content><ft-concept id="3" type="http://www.ft.com/ontology/company/PublicCompany">new</ft-concept></p></body>`,
+		"empty paragraphs":     "<body><p>a</p>  <p>b</p>\n\n<p><br/></p></body>",
+	}
+	for _, dir := range fixtureDirs(t) {
+		bodies[filepath.Base(dir)] = readFile(t, filepath.Join(dir, "content.html"))
+	}
+
+	for name, body := range bodies {
+		body := body
+		t.Run(name, func(t *testing.T) {
+			once, err := TransformBody(body)
+			if err != nil {
+				t.Fatalf("unexpected transformation error: %s", err.Error())
+			}
+			twice, err := TransformBody(once)
+			if err != nil {
+				t.Fatalf("unexpected error transforming the output again: %s", err.Error())
+			}
+			if once != twice {
+				t.Fatalf("transforming the output again changed it:\n%s", unifiedDiff(once, twice))
+			}
+		})
+	}
+}
+
+func TestTransformAlreadyTransformedElements(t *testing.T) {
+	body := `<body><ft-content id="1" type="http://www.ft.com/ontology/content/Article" url="http://api.ft.com/content/2">kept</ft-content><ft-concept id="3" type="http://www.ft.com/ontology/company/PublicCompany">new</ft-concept></body>`
+	expected := `<body><ft-content type="http://www.ft.com/ontology/content/Article" url="http://api.ft.com/content/2">kept</ft-content><ft-concept type="http://www.ft.com/ontology/company/PublicCompany" url="http://api.ft.com/organisations/3">new</ft-concept></body>`
+
+	got, report, err := Transform(body)
+	if err != nil {
+		t.Fatalf("unexpected transformation error: %s", err.Error())
+	}
+	if got != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s\n", expected, got)
+	}
+	if report.Count(RuleRemoveID) != 1 || report.Count(RuleRename) != 1 {
+		t.Fatalf("expected 1 removed id and 1 renamed element, got report:\n%s\n", report)
+	}
+}
@@ -0,0 +1,120 @@
+package bodytransformer
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// TweetOptions configures the ConvertTweets rule
+type TweetOptions struct {
+	// Wrapper is the element replacing the tweet embed, blockquote when empty
+	Wrapper string
+	// Class, when set, is the class attribute of the wrapper
+	Class string
+}
+
+// ConvertTweets replaces the twitter embeds with a portable card instead of removing them: the wrapper holds the tweet
+// text paragraph and a footer with the author line and a plain link to the status. The twitter widgets script
+// following the embed is removed.
+func ConvertTweets(opts TweetOptions) Option {
+	return func(cfg *config) {
+		if opts.Wrapper == "" {
+			opts.Wrapper = "blockquote"
+		}
+		cfg.tweets = &opts
+	}
+}
+
+// convertTweets replaces every twitter embed of the document with a card
+func convertTweets(doc *etree.Document, opts TweetOptions, report *Report) {
+	for _, tweet := range doc.FindElements("//blockquote[@class='twitter-tweet']") {
+		report.add(RuleConvertTweet, tweet)
+		parent := tweet.Parent()
+		if script := nextElement(tweet); script != nil && isTwitterWidgetsScript(script) {
+			removeElement(script, RuleConvertTweet, report)
+		}
+		parent.InsertChildAt(tweet.Index(), tweetCard(tweet, opts))
+		parent.RemoveChild(tweet)
+	}
+}
+
+func tweetCard(tweet *etree.Element, opts TweetOptions) *etree.Element {
+	card := etree.NewElement(opts.Wrapper)
+	if opts.Class != "" {
+		card.CreateAttr("class", opts.Class)
+	}
+
+	var status *etree.Element
+	var author strings.Builder
+	for _, token := range tweet.Child {
+		switch t := token.(type) {
+		case *etree.Element:
+			switch t.Tag {
+			case "p":
+				text := t.Copy()
+				text.Attr = nil
+				if lang := t.SelectAttr("lang"); lang != nil {
+					text.CreateAttr("lang", lang.Value)
+				}
+				card.AddChild(text)
+			case "a":
+				status = t
+			}
+		case *etree.CharData:
+			// the author line is the text between the tweet paragraph and the status link
+			if status == nil {
+				author.WriteString(t.Data)
+			}
+		}
+	}
+
+	footer := etree.NewElement("footer")
+	if line := strings.Join(strings.Fields(author.String()), " "); line != "" {
+		footer.CreateText(line)
+	}
+	if status != nil {
+		if footer.Text() != "" {
+			footer.CreateText(" ")
+		}
+		link := footer.CreateElement("a")
+		link.CreateAttr("href", statusURL(status.SelectAttrValue("href", "")))
+		link.SetText(elementText(status))
+	}
+	if len(footer.Child) > 0 {
+		card.AddChild(footer)
+	}
+	return card
+}
+
+// statusURL removes the query and fragment, e.g. the ref_src tracking parameter, from the status URL
+func statusURL(href string) string {
+	u, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
+
+// nextElement returns the element following el when only whitespace separates them
+func nextElement(el *etree.Element) *etree.Element {
+	parent := el.Parent()
+	for _, token := range parent.Child[el.Index()+1:] {
+		switch t := token.(type) {
+		case *etree.Element:
+			return t
+		case *etree.CharData:
+			if strings.TrimSpace(t.Data) != "" {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func isTwitterWidgetsScript(el *etree.Element) bool {
+	return el.Tag == "script" && strings.Contains(el.SelectAttrValue("src", ""), "platform.twitter.com/widgets.js")
+}
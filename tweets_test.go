@@ -0,0 +1,62 @@
+package bodytransformer
+
+import "testing"
+
+const tweetEmbed = `<blockquote class="twitter-tweet" data-lang="en" data-theme="light" data-dnt="true">` +
+	`<p lang="en" dir="ltr">Rates are on hold. <a href="https://twitter.com/hashtag/FOMC?src=hash&amp;ref_src=twsrc%5Etfw">#FOMC</a> statement: ` +
+	`<a href="https://t.co/AbCdEf1234">https://t.co/AbCdEf1234</a><br/>pic.twitter.com/XyZ</p>` +
+	`— Federal Reserve (@federalreserve) ` +
+	`<a href="https://twitter.com/federalreserve/status/1669423384474693633?ref_src=twsrc%5Etfw">June 15, 2023</a></blockquote>` + "\n" +
+	`<script async="" src="https://platform.twitter.com/widgets.js" charset="utf-8"></script>`
+
+func TestConvertTweets(t *testing.T) {
+	tests := map[string]struct {
+		body     string
+		opts     TweetOptions
+		expected string
+	}{
+		"default wrapper": {
+			body: `<body><p>Before</p>` + tweetEmbed + `<p>After</p></body>`,
+			expected: `<body><p>Before</p><blockquote><p lang="en">Rates are on hold. <a href="https://twitter.com/hashtag/FOMC?src=hash&amp;ref_src=twsrc%5Etfw">#FOMC</a> statement: ` +
+				`<a href="https://t.co/AbCdEf1234">https://t.co/AbCdEf1234</a><br/>pic.twitter.com/XyZ</p>` +
+				`<footer>— Federal Reserve (@federalreserve) <a href="https://twitter.com/federalreserve/status/1669423384474693633">June 15, 2023</a></footer></blockquote>` + "\n" +
+				`<p>After</p></body>`,
+		},
+		"configured wrapper": {
+			body: `<body><blockquote class="twitter-tweet"><p lang="fr" dir="ltr">Bonjour</p>— Jane (@jane) <a href="https://twitter.com/jane/status/1">May 1, 2023</a></blockquote></body>`,
+			opts: TweetOptions{Wrapper: "aside", Class: "tweet"},
+			expected: `<body><aside class="tweet"><p lang="fr">Bonjour</p>` +
+				`<footer>— Jane (@jane) <a href="https://twitter.com/jane/status/1">May 1, 2023</a></footer></aside></body>`,
+		},
+		"unrelated script is kept": {
+			body:     `<body><blockquote class="twitter-tweet"><p>Text</p></blockquote><script src="other.js"/></body>`,
+			expected: `<body><blockquote><p>Text</p></blockquote><script src="other.js"/></body>`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, report, err := Transform(test.body, ConvertTweets(test.opts))
+			if err != nil {
+				t.Fatalf("unexpected transformation error: %s", err.Error())
+			}
+			if got != test.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s\n", test.expected, got)
+			}
+			if report.Count(RuleRemoveTweet) != 0 || report.Count(RuleConvertTweet) == 0 {
+				t.Fatalf("expected tweets to be converted, got report:\n%s\n", report)
+			}
+		})
+	}
+}
+
+func TestTweetsRemovedByDefault(t *testing.T) {
+	got, err := TransformBody(`<body><p>Before</p>` + tweetEmbed + `</body>`)
+	if err != nil {
+		t.Fatalf("unexpected transformation error: %s", err.Error())
+	}
+	expected := `<body><p>Before</p>` + "\n" + `<script async="" src="https://platform.twitter.com/widgets.js" charset="utf-8"/></body>`
+	if got != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s\n", expected, got)
+	}
+}
@@ -0,0 +1,205 @@
+package bodytransformer
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/beevik/etree"
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultTypographyLocale is the locale whose quotation marks are used when TypographyOptions.Locale is not set
+const DefaultTypographyLocale = "en-GB"
+
+// TypographyOptions configures the NormaliseTypography rule
+type TypographyOptions struct {
+	// Locale selects the quotation marks straight quotes are converted to, e.g. "en-GB", "en-US", "de" or "fr".
+	// DefaultTypographyLocale is used when it is empty or not supported.
+	Locale string
+	// Space replaces the non-breaking and other Unicode space variants, a plain space when empty
+	Space string
+}
+
+// quoteMarks are the opening and closing quotation marks of a locale. Apostrophes are always ’.
+type quoteMarks struct {
+	open, close             string
+	singleOpen, singleClose string
+}
+
+var localeQuoteMarks = map[string]quoteMarks{
+	"en-GB": {open: "“", close: "”", singleOpen: "‘", singleClose: "’"},
+	"en-US": {open: "“", close: "”", singleOpen: "‘", singleClose: "’"},
+	"de":    {open: "„", close: "“", singleOpen: "‚", singleClose: "‘"},
+	"fr":    {open: "«", close: "»", singleOpen: "‹", singleClose: "›"},
+}
+
+// typographySkippedElements are the elements whose text is left as written
+var typographySkippedElements = []string{"pre", "code", "script", "style"}
+
+// NormaliseTypography normalises the text of the transformed body: it is put in Unicode NFC, non-breaking and other
+// space variants are replaced, straight quotes are converted to the quotation marks of the locale, "--" between
+// spaces or digits becomes an en dash and zero-width characters are removed. Only text is changed, attributes,
+// URLs written in the text and the content of pre and code elements are left untouched.
+func NormaliseTypography(opts TypographyOptions) Option {
+	return func(cfg *config) {
+		cfg.typography = &opts
+	}
+}
+
+// normaliseTypography applies the typography rule to every text node of the document
+func normaliseTypography(doc *etree.Document, opts TypographyOptions, report *Report) {
+	n := typographyNormaliser{quotes: quotesForLocale(opts.Locale), space: opts.Space, report: report}
+	if n.space == "" {
+		n.space = " "
+	}
+	n.walk(&doc.Element)
+}
+
+func quotesForLocale(locale string) quoteMarks {
+	if q, ok := localeQuoteMarks[locale]; ok {
+		return q
+	}
+	// fall back to the language of the locale, e.g. de-CH to de
+	if lang, _, found := strings.Cut(locale, "-"); found {
+		if q, ok := localeQuoteMarks[lang]; ok {
+			return q
+		}
+	}
+	return localeQuoteMarks[DefaultTypographyLocale]
+}
+
+type typographyNormaliser struct {
+	quotes quoteMarks
+	space  string
+	report *Report
+	// prev is the last character written in the current block, 0 at its start
+	prev rune
+}
+
+func (n *typographyNormaliser) walk(el *etree.Element) {
+	changed := false
+	for _, token := range el.Child {
+		switch t := token.(type) {
+		case *etree.CharData:
+			if text := n.normalise(t.Data); text != t.Data {
+				t.Data = text
+				changed = true
+			}
+		case *etree.Element:
+			if contains(typographySkippedElements, t.Tag) {
+				n.prev = 0
+				continue
+			}
+			// quotation marks do not continue across blocks
+			block := contains(blockElements, t.Tag)
+			if block {
+				n.prev = 0
+			}
+			n.walk(t)
+			if block {
+				n.prev = 0
+			}
+		}
+	}
+	if changed {
+		n.report.add(RuleTypography, el)
+	}
+}
+
+// normalise returns the normalised text, leaving the URLs found in it untouched
+func (n *typographyNormaliser) normalise(text string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range rawURLRegex.FindAllStringIndex(text, -1) {
+		sb.WriteString(n.normaliseText(text[last:loc[0]]))
+		sb.WriteString(text[loc[0]:loc[1]])
+		n.prev = '/'
+		last = loc[1]
+	}
+	sb.WriteString(n.normaliseText(text[last:]))
+	return sb.String()
+}
+
+func (n *typographyNormaliser) normaliseText(text string) string {
+	runes := []rune(norm.NFC.String(text))
+	var sb strings.Builder
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		next := runeAt(runes, i+1)
+		switch {
+		case isZeroWidth(r):
+			// zero width joiners are kept in emoji sequences
+			if r == '\u200d' && unicode.Is(unicode.So, n.prev) && unicode.Is(unicode.So, next) {
+				sb.WriteRune(r)
+			}
+			continue
+		case isSpaceVariant(r):
+			sb.WriteString(n.space)
+		case r == '"':
+			if opensQuotation(n.prev) {
+				sb.WriteString(n.quotes.open)
+			} else {
+				sb.WriteString(n.quotes.close)
+			}
+		case r == '\'':
+			switch {
+			case unicode.IsLetter(n.prev) || unicode.IsDigit(n.prev) || isDecade(runes[i+1:]):
+				sb.WriteString("’")
+			case opensQuotation(n.prev):
+				sb.WriteString(n.quotes.singleOpen)
+			default:
+				sb.WriteString(n.quotes.singleClose)
+			}
+		case r == '-' && next == '-' && isDashSafe(n.prev, runeAt(runes, i+2)):
+			sb.WriteString("–")
+			r = '–'
+			i++
+		default:
+			sb.WriteRune(r)
+		}
+		n.prev = r
+	}
+	return sb.String()
+}
+
+func runeAt(runes []rune, i int) rune {
+	if i < len(runes) {
+		return runes[i]
+	}
+	return 0
+}
+
+// opensQuotation reports whether a quote following prev opens a quotation
+func opensQuotation(prev rune) bool {
+	return prev == 0 || unicode.IsSpace(prev) || strings.ContainsRune("([{‘“„‚«‹—–-/", prev)
+}
+
+// isDecade reports whether an apostrophe followed by rest elides the century of a decade, as in the '90s
+func isDecade(rest []rune) bool {
+	return len(rest) >= 3 && unicode.IsDigit(rest[0]) && unicode.IsDigit(rest[1]) && rest[2] == 's'
+}
+
+// isDashSafe reports whether a "--" between prev and next can be replaced with an en dash: it must be a pair of
+// hyphens between spaces or between digits
+func isDashSafe(prev, next rune) bool {
+	spaced := (prev == 0 || unicode.IsSpace(prev)) && (next == 0 || unicode.IsSpace(next))
+	numeric := unicode.IsDigit(prev) && unicode.IsDigit(next)
+	return spaced || numeric
+}
+
+func isZeroWidth(r rune) bool {
+	switch r {
+	case '\u200b', '\u200c', '\u200d', '\u2060', '\ufeff':
+		return true
+	}
+	return false
+}
+
+// isSpaceVariant reports whether r is a Unicode space other than the ASCII whitespace
+func isSpaceVariant(r rune) bool {
+	switch {
+	case r == '\u00a0', r == '\u1680', r >= '\u2000' && r <= '\u200a', r == '\u202f', r == '\u205f', r == '\u3000':
+		return true
+	}
+	return false
+}
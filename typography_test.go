@@ -0,0 +1,75 @@
+package bodytransformer
+
+import "testing"
+
+func TestNormaliseTypography(t *testing.T) {
+	tests := map[string]struct {
+		body     string
+		opts     TypographyOptions
+		expected string
+	}{
+		"quotes and apostrophes": {
+			body:     `<body><p>"It's the '90s again," he said. 'Not quite.'</p></body>`,
+			expected: `<body><p>“It’s the ’90s again,” he said. ‘Not quite.’</p></body>`,
+		},
+		"quotes across elements": {
+			body:     `<body><p>He called it "<em>transitory</em>".</p><p>"Next</p></body>`,
+			expected: `<body><p>He called it “<em>transitory</em>”.</p><p>“Next</p></body>`,
+		},
+		"locale quotes": {
+			body:     `<body><p>"Ja", sagte er.</p></body>`,
+			opts:     TypographyOptions{Locale: "de-CH"},
+			expected: `<body><p>„Ja“, sagte er.</p></body>`,
+		},
+		"spaces": {
+			body:     "<body><p>£1\u00a0000 and 5\u202f% in\u2003total</p></body>",
+			expected: "<body><p>£1 000 and 5 % in total</p></body>",
+		},
+		"space target": {
+			body:     "<body><p>Mr\u2009Smith</p></body>",
+			opts:     TypographyOptions{Space: "\u00a0"},
+			expected: "<body><p>Mr\u00a0Smith</p></body>",
+		},
+		"dashes": {
+			body:     `<body><p>Rates -- for now -- hold in 2020--2021, not in the ---- row or x--y.</p></body>`,
+			expected: `<body><p>Rates – for now – hold in 2020–2021, not in the ---- row or x--y.</p></body>`,
+		},
+		"zero width characters": {
+			body:     "<body><p>co\u200boperate\ufeff \U0001F469\u200d\U0001F4BB</p></body>",
+			expected: "<body><p>cooperate \U0001F469\u200d\U0001F4BB</p></body>",
+		},
+		"nfc": {
+			body:     "<body><p>Cafe\u0301</p></body>",
+			expected: "<body><p>Caf\u00e9</p></body>",
+		},
+		"attributes, urls and code untouched": {
+			body: "<body><p><a href=\"http://www.ft.com/a--b?q='x'\" title=\"it's\">it's</a> see https://ft.com/x--y\u200b's</p>" +
+				"<pre>\"raw\" -- text</pre></body>",
+			expected: "<body><p><a href=\"http://www.ft.com/a--b?q='x'\" title=\"it's\">it’s</a> see https://ft.com/x--y\u200b's</p>" +
+				"<pre>\"raw\" -- text</pre></body>",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, report, err := Transform(test.body, NormaliseTypography(test.opts))
+			if err != nil {
+				t.Fatalf("unexpected transformation error: %s", err.Error())
+			}
+			if got != test.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s\n", test.expected, got)
+			}
+			if report.Count(RuleTypography) == 0 {
+				t.Fatalf("expected typography actions in the report, got:\n%s\n", report)
+			}
+
+			again, _, err := Transform(got, NormaliseTypography(test.opts))
+			if err != nil {
+				t.Fatalf("unexpected transformation error: %s", err.Error())
+			}
+			if again != got {
+				t.Fatalf("expected normalisation to be stable, got:\n%s\n", again)
+			}
+		})
+	}
+}